@@ -13,9 +13,12 @@ type Args struct {
 	Endpoint    string
 	Username    string
 	Password    string
+	ApiKey      string
+	BearerToken string
 	Insecure    *bool
 	Config      string
 	CompactMode bool
+	DebugLog    string
 }
 
 func Parse() (*Args, error) {
@@ -27,9 +30,12 @@ func Parse() (*Args, error) {
 	flag.StringVarP(&args.Endpoint, "endpoint", "e", "", "the cluster endpoint to query (takes precedence over cluster)")
 	flag.StringVarP(&args.Username, "username", "u", "", "the username to use for endpoint authentication if provided as argument or none is specified in the configuration")
 	flag.StringVarP(&args.Password, "password", "p", "", "the pssword to use for endpoint authentication if provided as argument or none is specified in the configuration")
+	flag.StringVar(&args.ApiKey, "api-key", "", "the encoded API key to use for endpoint authentication (takes precedence over bearer token and username)")
+	flag.StringVar(&args.BearerToken, "bearer-token", "", "the bearer token to use for endpoint authentication (takes precedence over username)")
 	flag.BoolVarP(&insecure, "insecure", "k", false, "the pssword to use for endpoint authentication if provided as argument or none is specified in the configuration")
 	flag.StringVarP(&args.Config, "config", "f", "", "the configuration file to use")
 	flag.BoolVarP(&args.CompactMode, "compact", "m", false, "compact mode (shows only cluster overview):")
+	flag.StringVar(&args.DebugLog, "debug-log", "", "the file to write debug messages (e.g. request retries) to")
 
 	flag.Parse()
 
@@ -38,10 +44,10 @@ func Parse() (*Args, error) {
 		if err != nil || url.Scheme == "" || url.Host == "" {
 			return nil, errors.New("Endpoint must be an URL.")
 		}
+	}
 
-		if args.Username == "" || args.Password == "" {
-			return nil, errors.New("Credentials (Username, Password) must be used when specifying Endpoint.")
-		}
+	if args.ApiKey != "" && args.BearerToken != "" {
+		return nil, errors.New("API key and bearer token must not be used together.")
 	}
 
 	if args.Username != "" && args.Password == "" {
@@ -12,22 +12,65 @@ import (
 )
 
 type Config struct {
-	Clusters []ClusterConfig `mapstructure:"clusters" validate:"unique=Alias,unique=Endpoint,dive"`
+	Clusters []ClusterConfig `mapstructure:"clusters" validate:"unique=Alias,dive"`
 	Http     HttpConfig      `mapstructure:"http"`
 	General  GeneralConfig   `mapstructure:"general"`
 	Theme    ThemeConfig     `mapstructure:"theme"`
 }
 
 type ClusterConfig struct {
-	Alias    string `mapstructure:"alias" validate:"required"`
-	Endpoint string `mapstructure:"endpoint" validate:"required,http_url"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	Alias       string `mapstructure:"alias" validate:"required"`
+	Endpoint    string `mapstructure:"endpoint" validate:"required_without=Endpoints,omitempty,http_url"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	ApiKey      string `mapstructure:"api_key" validate:"excluded_with=BearerToken"`
+	BearerToken string `mapstructure:"bearer_token"`
+
+	Endpoints     []string `mapstructure:"endpoints" validate:"omitempty,dive,http_url"`
+	RoundRobin    bool     `mapstructure:"round_robin"`
+	DiscoverNodes bool     `mapstructure:"discover_nodes"`
+
+	CaFile         string `mapstructure:"ca_file" validate:"omitempty,file"`
+	ClientCertFile string `mapstructure:"client_cert_file" validate:"required_with=ClientKeyFile,omitempty,file"`
+	ClientKeyFile  string `mapstructure:"client_key_file" validate:"required_with=ClientCertFile,omitempty,file"`
+	ServerName     string `mapstructure:"server_name"`
+
+	Http ClusterHttpConfig `mapstructure:"http"`
 }
 
 type HttpConfig struct {
-	Timeout  uint `mapstructure:"timeout"`
-	Insecure bool `mapstructure:"insecure"`
+	Timeout  uint              `mapstructure:"timeout"`
+	Insecure bool              `mapstructure:"insecure"`
+	Proxy    string            `mapstructure:"proxy" validate:"omitempty,url"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Retry    RetryConfig       `mapstructure:"retry"`
+}
+
+// RetryConfig defines how often and when failed requests are retried. Delays
+// are given in milliseconds.
+type RetryConfig struct {
+	MaxAttempts uint  `mapstructure:"max_attempts" validate:"min=1"`
+	BaseDelay   uint  `mapstructure:"base_delay"`
+	MaxDelay    uint  `mapstructure:"max_delay"`
+	StatusCodes []int `mapstructure:"status_codes"`
+}
+
+// ClusterHttpConfig overrides the global HttpConfig for a single cluster.
+// Values which are not set fall back to the global configuration.
+type ClusterHttpConfig struct {
+	Timeout  *uint              `mapstructure:"timeout"`
+	Insecure *bool              `mapstructure:"insecure"`
+	Proxy    string             `mapstructure:"proxy" validate:"omitempty,url"`
+	Headers  map[string]string  `mapstructure:"headers"`
+	Retry    ClusterRetryConfig `mapstructure:"retry"`
+}
+
+// ClusterRetryConfig overrides the global RetryConfig for a single cluster.
+type ClusterRetryConfig struct {
+	MaxAttempts *uint `mapstructure:"max_attempts" validate:"omitempty,min=1"`
+	BaseDelay   *uint `mapstructure:"base_delay"`
+	MaxDelay    *uint `mapstructure:"max_delay"`
+	StatusCodes []int `mapstructure:"status_codes"`
 }
 
 type GeneralConfig struct {
@@ -83,6 +126,10 @@ func Load(configFile string) (*Config, error) {
 	v.SetDefault("general.refresh_interval", constants.DefaultRefreshIntervalSeconds)
 	v.SetDefault("http.timeout", constants.DefaultHttpTimeout)
 	v.SetDefault("http.insecure", constants.DefaultHttpInsecure)
+	v.SetDefault("http.retry.max_attempts", constants.DefaultHttpRetryMaxAttempts)
+	v.SetDefault("http.retry.base_delay", constants.DefaultHttpRetryBaseDelay)
+	v.SetDefault("http.retry.max_delay", constants.DefaultHttpRetryMaxDelay)
+	v.SetDefault("http.retry.status_codes", constants.DefaultHttpRetryStatusCodes)
 
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -106,6 +153,60 @@ func Load(configFile string) (*Config, error) {
 
 }
 
+// AllEndpoints returns the endpoint followed by the additional endpoints of the
+// cluster.
+func (c *ClusterConfig) AllEndpoints() []string {
+	var endpoints []string
+	if c.Endpoint != "" {
+		endpoints = append(endpoints, c.Endpoint)
+	}
+	return append(endpoints, c.Endpoints...)
+}
+
+// HttpConfig merges the HTTP configuration of the cluster over the global HTTP
+// configuration. Headers are merged by name.
+func (c *ClusterConfig) HttpConfig(global HttpConfig) HttpConfig {
+	httpConfig := global
+
+	if c.Http.Timeout != nil {
+		httpConfig.Timeout = *c.Http.Timeout
+	}
+
+	if c.Http.Insecure != nil {
+		httpConfig.Insecure = *c.Http.Insecure
+	}
+
+	if c.Http.Proxy != "" {
+		httpConfig.Proxy = c.Http.Proxy
+	}
+
+	if c.Http.Retry.MaxAttempts != nil {
+		httpConfig.Retry.MaxAttempts = *c.Http.Retry.MaxAttempts
+	}
+
+	if c.Http.Retry.BaseDelay != nil {
+		httpConfig.Retry.BaseDelay = *c.Http.Retry.BaseDelay
+	}
+
+	if c.Http.Retry.MaxDelay != nil {
+		httpConfig.Retry.MaxDelay = *c.Http.Retry.MaxDelay
+	}
+
+	if c.Http.Retry.StatusCodes != nil {
+		httpConfig.Retry.StatusCodes = c.Http.Retry.StatusCodes
+	}
+
+	httpConfig.Headers = make(map[string]string)
+	for name, value := range global.Headers {
+		httpConfig.Headers[name] = value
+	}
+	for name, value := range c.Http.Headers {
+		httpConfig.Headers[name] = value
+	}
+
+	return httpConfig
+}
+
 func Validate(config *Config) error {
 	validate := validator.New(validator.WithRequiredStructEnabled())
 	return validate.Struct(config)
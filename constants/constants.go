@@ -13,6 +13,13 @@ const (
 	DefaultRefreshIntervalSeconds = 5
 	DefaultHttpTimeout            = 60
 	DefaultHttpInsecure           = false
+	DefaultHttpRetryMaxAttempts   = 3
+	DefaultHttpRetryBaseDelay     = 200
+	DefaultHttpRetryMaxDelay      = 5000
 
 	RedactedPassword = "*****"
 )
+
+var (
+	DefaultHttpRetryStatusCodes = []int{429, 502, 503, 504}
+)
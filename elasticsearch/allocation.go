@@ -0,0 +1,69 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	allocationExplainPath = "/_cluster/allocation/explain"
+)
+
+// AllocationExplanation describes why a shard copy is (not) allocated and
+// the decision of every node.
+type AllocationExplanation struct {
+	Index          string `json:"index"`
+	Shard          int    `json:"shard"`
+	Primary        bool   `json:"primary"`
+	CurrentState   string `json:"current_state"`
+	UnassignedInfo struct {
+		Reason               string `json:"reason"`
+		At                   string `json:"at"`
+		LastAllocationStatus string `json:"last_allocation_status"`
+		Details              string `json:"details"`
+	} `json:"unassigned_info"`
+	CanAllocate             string                   `json:"can_allocate"`
+	AllocateExplanation     string                   `json:"allocate_explanation"`
+	NodeAllocationDecisions []NodeAllocationDecision `json:"node_allocation_decisions"`
+}
+
+type NodeAllocationDecision struct {
+	NodeId        string              `json:"node_id"`
+	NodeName      string              `json:"node_name"`
+	NodeDecision  string              `json:"node_decision"`
+	WeightRanking int                 `json:"weight_ranking"`
+	Deciders      []AllocationDecider `json:"deciders"`
+}
+
+// AllocationDecider is the decision of an allocation decider (e.g. disk
+// threshold, same shard, awareness or filter) for a node.
+type AllocationDecider struct {
+	Decider     string `json:"decider"`
+	Decision    string `json:"decision"`
+	Explanation string `json:"explanation"`
+}
+
+// ExplainAllocation explains the allocation of the primary or a replica of the
+// shard.
+func (c *Client) ExplainAllocation(ctx context.Context, index string, shard int, primary bool) (*AllocationExplanation, error) {
+	request, err := json.Marshal(map[string]any{
+		"index":   index,
+		"shard":   shard,
+		"primary": primary,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.post(ctx, allocationExplainPath, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var explanation AllocationExplanation
+	if err = json.Unmarshal(body, &explanation); err != nil {
+		return nil, err
+	}
+
+	return &explanation, nil
+}
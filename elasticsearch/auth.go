@@ -0,0 +1,94 @@
+package elasticsearch
+
+import (
+	"errors"
+	"esmon/config"
+	"net/http"
+)
+
+type Credentials struct {
+	Username    string
+	Password    string
+	ApiKey      string
+	BearerToken string
+}
+
+// Authenticator adds the authentication information of a cluster to a
+// request.
+type Authenticator interface {
+	Authenticate(req *http.Request)
+}
+
+type NoAuth struct{}
+
+func (a NoAuth) Authenticate(req *http.Request) {}
+
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(req *http.Request) {
+	req.SetBasicAuth(a.Username, a.Password)
+}
+
+// ApiKeyAuth expects the base64 encoded API key as returned in the "encoded"
+// field by the create API key API.
+type ApiKeyAuth struct {
+	ApiKey string
+}
+
+func (a ApiKeyAuth) Authenticate(req *http.Request) {
+	req.Header.Set("Authorization", "ApiKey "+a.ApiKey)
+}
+
+type BearerTokenAuth struct {
+	Token string
+}
+
+func (a BearerTokenAuth) Authenticate(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+}
+
+// Authenticator returns the authentication method for the credentials. API keys
+// take precedence over bearer tokens, which take precedence over username and
+// password. Without any credentials, requests are sent unauthenticated.
+func (c *Credentials) Authenticator() Authenticator {
+	switch {
+	case c.ApiKey != "":
+		return ApiKeyAuth{ApiKey: c.ApiKey}
+	case c.BearerToken != "":
+		return BearerTokenAuth{Token: c.BearerToken}
+	case c.Username != "":
+		return BasicAuth{Username: c.Username, Password: c.Password}
+	default:
+		return NoAuth{}
+	}
+}
+
+func GetCredentials(clusterConfig *config.ClusterConfig, defaultCredentials *Credentials) (*Credentials, error) {
+	credentials := *defaultCredentials
+
+	if clusterConfig.Username != "" {
+		credentials.Username = clusterConfig.Username
+	}
+
+	if clusterConfig.Password != "" {
+		credentials.Password = clusterConfig.Password
+	}
+
+	if clusterConfig.ApiKey != "" {
+		credentials.ApiKey = clusterConfig.ApiKey
+	}
+
+	if clusterConfig.BearerToken != "" {
+		credentials.BearerToken = clusterConfig.BearerToken
+	}
+
+	if credentials.ApiKey == "" && credentials.BearerToken == "" &&
+		credentials.Username != "" && credentials.Password == "" {
+		return nil, errors.New("Neither cluster nor default password were provided for username " + credentials.Username + ".")
+	}
+
+	return &credentials, nil
+}
@@ -0,0 +1,297 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"esmon/config"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// FetchData issues all requests concurrently. The endpoints of the pool
+	// share the transport, which keeps idle connections per endpoint, and
+	// without round robin all requests go to the same endpoint. So it has to
+	// keep at least that many idle connections per endpoint, the Go default
+	// of two would close the remaining connections after every refresh.
+	maxIdleConnsPerHost = 10
+
+	discoveryInterval = 5 * time.Minute
+)
+
+type Client struct {
+	endpoints     *endpointPool
+	authenticator Authenticator
+	headers       map[string]string
+	httpClient    *http.Client
+	retryPolicy   retryPolicy
+
+	// number of retries since the client was created
+	retries atomic.Int64
+
+	version      *Version
+	versionMutex sync.Mutex
+
+	discoverNodes  bool
+	discoveryAt    time.Time
+	discoveryMutex sync.Mutex
+}
+
+func NewClient(clusterConfig *config.ClusterConfig, credentials *Credentials, httpConfig config.HttpConfig) (*Client, error) {
+	tlsConfig, err := TLSConfig(clusterConfig, httpConfig.Insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+
+	if httpConfig.Proxy != "" {
+		proxyUrl, err := url.Parse(httpConfig.Proxy)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to parse proxy URL: %s", err))
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	return &Client{
+		endpoints:     newEndpointPool(clusterConfig.AllEndpoints(), clusterConfig.RoundRobin),
+		discoverNodes: clusterConfig.DiscoverNodes,
+		authenticator: credentials.Authenticator(),
+		headers:       httpConfig.Headers,
+		retryPolicy:   newRetryPolicy(httpConfig.Retry),
+		httpClient: &http.Client{
+			Timeout:   time.Duration(httpConfig.Timeout) * time.Second,
+			Transport: transport,
+		},
+	}, nil
+}
+
+// Close releases the idle connections held by the client. The client must not
+// be used afterwards.
+func (c *Client) Close() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// CurrentEndpoint returns the endpoint which was selected for the latest
+// request.
+func (c *Client) CurrentEndpoint() string {
+	return c.endpoints.currentURL()
+}
+
+// get returns the response body of the request.
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	return c.send(ctx, http.MethodGet, path, nil)
+}
+
+// post returns the response body of the request with the JSON request body.
+// It is only used for APIs which do not modify the cluster, so it is retried
+// like a GET request.
+func (c *Client) post(ctx context.Context, path string, requestBody []byte) ([]byte, error) {
+	return c.send(ctx, http.MethodPost, path, requestBody)
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, requestBody []byte) ([]byte, error) {
+	var body []byte
+	err := c.read(ctx, method, path, requestBody, func(reader io.Reader) error {
+		var err error
+		body, err = io.ReadAll(reader)
+		return err
+	})
+	return body, err
+}
+
+// decode passes the response body of the request to decodeFunc as a stream,
+// so large responses do not have to be held in memory. decodeFunc is called
+// again for every retry and must discard the results of previous calls.
+func (c *Client) decode(ctx context.Context, path string, decodeFunc func(decoder *json.Decoder) error) error {
+	return c.read(ctx, http.MethodGet, path, nil, func(reader io.Reader) error {
+		return decodeFunc(json.NewDecoder(reader))
+	})
+}
+
+// read sends the request and retries it according to the retry policy if it
+// fails with a transient error.
+func (c *Client) read(ctx context.Context, method string, path string, requestBody []byte, readFunc func(reader io.Reader) error) error {
+	for retry := 1; ; retry++ {
+		err := c.readFromPool(ctx, method, path, requestBody, readFunc)
+		if retry >= c.retryPolicy.maxAttempts || ctx.Err() != nil || !c.retryPolicy.retryable(err) {
+			return err
+		}
+
+		delay := c.retryPolicy.delay(retry)
+		log.Printf("Retrying %s in %s (attempt %d of %d): %s", path, delay, retry+1, c.retryPolicy.maxAttempts, err)
+		c.retries.Add(1)
+
+		if err := wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Retries returns the number of retries since the client was created.
+func (c *Client) Retries() int64 {
+	return c.retries.Load()
+}
+
+// readFromPool sends the request to the endpoints of the pool until one of
+// them is able to respond. Endpoints which are unreachable or respond with a
+// gateway error are marked dead.
+func (c *Client) readFromPool(ctx context.Context, method string, path string, requestBody []byte, readFunc func(reader io.Reader) error) error {
+	var err error
+
+	for attempt := 0; attempt < c.endpoints.size(); attempt++ {
+		endpoint := c.endpoints.next()
+
+		err = c.request(ctx, method, endpoint.url+path, requestBody, readFunc)
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if isEndpointFailure(err) {
+			log.Printf("Marking endpoint %s dead: %s", endpoint.url, err)
+			c.endpoints.markDead(endpoint)
+			continue
+		}
+
+		c.endpoints.markAlive(endpoint)
+		return err
+	}
+
+	return err
+}
+
+func isEndpointFailure(err error) bool {
+	if err == nil || isDecodeError(err) {
+		return false
+	}
+
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode == http.StatusBadGateway ||
+			apiError.StatusCode == http.StatusServiceUnavailable ||
+			apiError.StatusCode == http.StatusGatewayTimeout
+	}
+
+	return true
+}
+
+// request passes the body of a successful response to readFunc. Errors of
+// readFunc which are not caused by reading the body are decode errors.
+func (c *Client) request(ctx context.Context, method string, requestUrl string, requestBody []byte, readFunc func(reader io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, bytes.NewReader(requestBody))
+	if err != nil {
+		return err
+	}
+
+	if requestBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	c.authenticator.Authenticate(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return newAPIError(resp.StatusCode, body)
+	}
+
+	reader := &readErrorRecorder{reader: resp.Body}
+	if err = readFunc(reader); err != nil {
+		if reader.err != nil {
+			return reader.err
+		}
+		return &decodeError{err: err}
+	}
+
+	return nil
+}
+
+// discover adds the HTTP publish addresses of all nodes to the endpoint pool.
+// Discovery is best-effort and attempted at most once per discoveryInterval,
+// the configured endpoints continue to be used if it fails. Failed attempts
+// wait for the interval as well, so a cluster rejecting the request (e.g. with
+// 403) does not delay every refresh.
+func (c *Client) discover(ctx context.Context) {
+	c.discoveryMutex.Lock()
+	defer c.discoveryMutex.Unlock()
+
+	if !c.discoverNodes || time.Since(c.discoveryAt) < discoveryInterval {
+		return
+	}
+	c.discoveryAt = time.Now()
+
+	body, err := c.get(ctx, nodesHttpPath)
+	if err != nil {
+		log.Printf("Node discovery failed: %s", err)
+		return
+	}
+
+	var nodesHttp struct {
+		Nodes map[string]struct {
+			Http struct {
+				PublishAddress string `json:"publish_address"`
+			} `json:"http"`
+		} `json:"nodes"`
+	}
+	if err = json.Unmarshal(body, &nodesHttp); err != nil {
+		return
+	}
+
+	seed, err := url.Parse(c.endpoints.currentURL())
+	if err != nil {
+		return
+	}
+
+	// the nodes are reached like the seed, e.g. through the same path prefix
+	var urls []string
+	for _, node := range nodesHttp.Nodes {
+		if address := publishAddressHost(node.Http.PublishAddress); address != "" {
+			nodeURL := *seed
+			nodeURL.Host = address
+			urls = append(urls, nodeURL.String())
+		}
+	}
+
+	c.endpoints.add(urls)
+}
+
+// publishAddressHost converts a publish address, which is either ip:port or
+// hostname/ip:port, into host:port. The hostname is preferred as the IP address
+// is usually not contained in the node certificate.
+func publishAddressHost(publishAddress string) string {
+	hostname, address, found := strings.Cut(publishAddress, "/")
+	if !found {
+		return publishAddress
+	}
+
+	_, port, err := net.SplitHostPort(address)
+	if hostname == "" || err != nil {
+		return address
+	}
+
+	return net.JoinHostPort(hostname, port)
+}
@@ -0,0 +1,47 @@
+package elasticsearch
+
+import (
+	"context"
+	"esmon/config"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDiscoverKeepsPathPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/elasticsearch/_nodes/http" {
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"nodes":{"a":{"http":{"publish_address":"node-1/10.0.0.1:9200"}},"b":{"http":{"publish_address":"10.0.0.2:9200"}}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(
+		&config.ClusterConfig{Alias: "test", Endpoint: server.URL + "/elasticsearch", DiscoverNodes: true},
+		&Credentials{},
+		config.HttpConfig{Timeout: 5, Retry: config.RetryConfig{MaxAttempts: 1}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	client.discover(context.Background())
+
+	// the seed is followed by the discovered nodes
+	var urls []string
+	for _, endpoint := range client.endpoints.endpoints[1:] {
+		urls = append(urls, endpoint.url)
+	}
+	sort.Strings(urls)
+
+	want := []string{"http://10.0.0.2:9200/elasticsearch", "http://node-1:9200/elasticsearch"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("got %v, want %v", urls, want)
+	}
+}
@@ -0,0 +1,95 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+)
+
+var (
+	dataStreamsPath     = "/_data_stream?filter_path=" + filterPath("data_streams", DataStream{})
+	dataStreamStatsPath = "/_data_stream/_stats?human&filter_path=" + filterPath("data_streams", DataStreamStats{})
+)
+
+// DataStream is a data stream as listed by _data_stream. Its backing indices
+// are ordered from the oldest to the write index.
+type DataStream struct {
+	Name           string `json:"name"`
+	TimestampField struct {
+		Name string `json:"name"`
+	} `json:"timestamp_field"`
+	Indices []struct {
+		IndexName string `json:"index_name"`
+	} `json:"indices"`
+	Generation int             `json:"generation"`
+	Status     string          `json:"status"`
+	Template   string          `json:"template"`
+	IlmPolicy  string          `json:"ilm_policy"`
+	Stats      DataStreamStats // manually added while fetching
+}
+
+// DataStreamStats are the stats of a data stream as reported by
+// _data_stream/_stats. The maximum timestamp is in epoch milliseconds.
+type DataStreamStats struct {
+	DataStream       string `json:"data_stream"`
+	BackingIndices   int    `json:"backing_indices"`
+	StoreSize        string `json:"store_size"`
+	StoreSizeBytes   int64  `json:"store_size_bytes"`
+	MaximumTimestamp int64  `json:"maximum_timestamp"`
+}
+
+// BackingIndices returns the names of the backing indices.
+func (d DataStream) BackingIndices() []string {
+	names := make([]string, 0, len(d.Indices))
+	for _, index := range d.Indices {
+		names = append(names, index.IndexName)
+	}
+	return names
+}
+
+// fetchDataStreams returns the data streams with their stats. Clusters without
+// data streams have none.
+func (c *Client) fetchDataStreams(ctx context.Context, version Version) (*[]DataStream, error) {
+	var dataStreams []DataStream
+
+	if !version.Supports(DataStreamCapability) {
+		return &dataStreams, nil
+	}
+
+	body, err := c.get(ctx, dataStreamsPath)
+	if err != nil {
+		if isUnsupportedEndpoint(err) {
+			return &dataStreams, nil
+		}
+		return nil, err
+	}
+
+	var list struct {
+		DataStreams []DataStream `json:"data_streams"`
+	}
+	if err = json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	dataStreams = list.DataStreams
+
+	body, err = c.get(ctx, dataStreamStatsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats struct {
+		DataStreams []DataStreamStats `json:"data_streams"`
+	}
+	if err = json.Unmarshal(body, &stats); err != nil {
+		return nil, err
+	}
+
+	statsByName := make(map[string]DataStreamStats, len(stats.DataStreams))
+	for _, dataStreamStats := range stats.DataStreams {
+		statsByName[dataStreamStats.DataStream] = dataStreamStats
+	}
+	for index := range dataStreams {
+		dataStreams[index].Stats = statsByName[dataStreams[index].Name]
+	}
+
+	return &dataStreams, nil
+}
@@ -0,0 +1,124 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// decodeError reports a response body which could not be decoded. Unlike
+// errors while reading the body, it is neither retried nor attributed to the
+// endpoint.
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("Unable to decode response: %s", e.err)
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+func isDecodeError(err error) bool {
+	var decodeErr *decodeError
+	return errors.As(err, &decodeErr)
+}
+
+// readErrorRecorder remembers the first error of the underlying reader, so
+// read errors can be told apart from decode errors after decoding failed.
+type readErrorRecorder struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// The following helpers walk a response with a token decoder, so only a
+// single entry of a large object has to be held in memory at a time.
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return errors.New(fmt.Sprintf("Expected %s but found %v", delim, token))
+	}
+
+	return nil
+}
+
+// decodeObject calls decodeValue for every key of the next object. decodeValue
+// has to consume the value of the key.
+func decodeObject(decoder *json.Decoder, decodeValue func(key string) error) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return errors.New(fmt.Sprintf("Expected a key but found %v", token))
+		}
+
+		if err = decodeValue(key); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(decoder, '}')
+}
+
+// decodeArray calls decodeValue for every element of the next array.
+// decodeValue has to consume the element.
+func decodeArray(decoder *json.Decoder, decodeValue func() error) error {
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		if err := decodeValue(); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(decoder, ']')
+}
+
+// skipValue consumes the next value without decoding it.
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: `{}`,
+			want:  map[string]any{},
+		},
+		{
+			name:  "scalar values",
+			input: `{"a":1,"b":"two","c":true,"d":null}`,
+			want:  map[string]any{"a": 1.0, "b": "two", "c": true, "d": nil},
+		},
+		{
+			name:  "nested values",
+			input: `{"a":{"b":{"c":[1,{"d":2}]}},"e":[[],{}]}`,
+			want: map[string]any{
+				"a": map[string]any{"b": map[string]any{"c": []any{1.0, map[string]any{"d": 2.0}}}},
+				"e": []any{[]any{}, map[string]any{}},
+			},
+		},
+		{name: "array", input: `[1,2]`, wantErr: true},
+		{name: "string", input: `"a"`, wantErr: true},
+		{name: "number", input: `1`, wantErr: true},
+		{name: "empty input", input: ``, wantErr: true},
+		{name: "mismatched delimiter", input: `{"a":1]`, wantErr: true},
+		{name: "truncated key", input: `{"a`, wantErr: true},
+		{name: "truncated value", input: `{"a":{"b":1`, wantErr: true},
+		{name: "truncated object", input: `{"a":1`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(test.input))
+
+			got := make(map[string]any)
+			err := decodeObject(decoder, func(key string) error {
+				var value any
+				if err := decoder.Decode(&value); err != nil {
+					return err
+				}
+				got[key] = value
+				return nil
+			})
+
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeObjectPassesValueErrors(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"a":1}`))
+	errValue := io.ErrUnexpectedEOF
+
+	err := decodeObject(decoder, func(key string) error {
+		return errValue
+	})
+	if err != errValue {
+		t.Errorf("expected the error of decodeValue, got %v", err)
+	}
+}
+
+func TestDecodeArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []any
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: `[]`,
+		},
+		{
+			name:  "scalar values",
+			input: `[1,"two",false,null]`,
+			want:  []any{1.0, "two", false, nil},
+		},
+		{
+			name:  "nested values",
+			input: `[{"a":[1,2]},[[3]],{}]`,
+			want:  []any{map[string]any{"a": []any{1.0, 2.0}}, []any{[]any{3.0}}, map[string]any{}},
+		},
+		{name: "object", input: `{"a":1}`, wantErr: true},
+		{name: "string", input: `"a"`, wantErr: true},
+		{name: "empty input", input: ``, wantErr: true},
+		{name: "mismatched delimiter", input: `[1}`, wantErr: true},
+		{name: "truncated element", input: `[{"a":1`, wantErr: true},
+		{name: "truncated array", input: `[1,2`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(test.input))
+
+			var got []any
+			err := decodeArray(decoder, func() error {
+				var value any
+				if err := decoder.Decode(&value); err != nil {
+					return err
+				}
+				got = append(got, value)
+				return nil
+			})
+
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSkipValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "number", value: `1`},
+		{name: "string", value: `"a"`},
+		{name: "null", value: `null`},
+		{name: "empty object", value: `{}`},
+		{name: "empty array", value: `[]`},
+		{name: "nested object", value: `{"a":{"b":[1,{"c":"}"}]},"d":2}`},
+		{name: "nested array", value: `[[1,[2]],{"a":"]"},[]]`},
+		{name: "truncated object", value: `{"a":{"b":1}`, wantErr: true},
+		{name: "truncated array", value: `[1,[2]`, wantErr: true},
+		{name: "mismatched delimiter", value: `{"a":1]`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the value is followed by a key, which has to be the next token
+			// after the value was skipped
+			input := `{"skipped":` + test.value
+			if !test.wantErr {
+				input += `,"next":true}`
+			}
+
+			decoder := json.NewDecoder(strings.NewReader(input))
+			for _, want := range []json.Token{json.Delim('{'), "skipped"} {
+				if token, err := decoder.Token(); err != nil || token != want {
+					t.Fatalf("expected %v, got %v (%v)", want, token, err)
+				}
+			}
+
+			err := skipValue(decoder)
+			if test.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if token, err := decoder.Token(); err != nil || token != "next" {
+				t.Errorf("expected the next key after the value, got %v (%v)", token, err)
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeIndexStats compares decoding a large index stats response
+// from a token stream with reading and unmarshaling it as a whole.
+func BenchmarkDecodeIndexStats(b *testing.B) {
+	body := readFixture(b, indexStatsFixture)
+
+	benchmarks := []struct {
+		name   string
+		decode func(reader io.Reader) ([]IndexStats, error)
+	}{
+		{"stream", streamIndexStats},
+		{"unmarshal", unmarshalIndexStats},
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+
+			for i := 0; i < b.N; i++ {
+				indexStats, err := benchmark.decode(bytes.NewReader(body))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(indexStats) == 0 {
+					b.Fatal("expected index stats in the fixture")
+				}
+			}
+		})
+	}
+}
+
+// streamIndexStats decodes the index stats like fetchIndexStats.
+func streamIndexStats(reader io.Reader) ([]IndexStats, error) {
+	var indexStatsArray []IndexStats
+
+	decoder := json.NewDecoder(reader)
+	err := decodeObject(decoder, func(key string) error {
+		if key != "indices" {
+			return skipValue(decoder)
+		}
+
+		return decodeObject(decoder, func(name string) error {
+			indexStats := IndexStats{Name: name}
+			if err := decoder.Decode(&indexStats); err != nil {
+				return err
+			}
+			indexStatsArray = append(indexStatsArray, indexStats)
+			return nil
+		})
+	})
+
+	return indexStatsArray, err
+}
+
+// unmarshalIndexStats decodes the index stats like fetchIndexStats did
+// before it was based on a token stream.
+func unmarshalIndexStats(reader io.Reader) ([]IndexStats, error) {
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawMap map[string]json.RawMessage
+	if err = json.Unmarshal(body, &rawMap); err != nil {
+		return nil, err
+	}
+
+	var indexInfos map[string]json.RawMessage
+	if err = json.Unmarshal(rawMap["indices"], &indexInfos); err != nil {
+		return nil, err
+	}
+
+	var indexStatsArray []IndexStats
+	for name, indexInfo := range indexInfos {
+		indexStats := IndexStats{Name: name}
+		if err = json.Unmarshal(indexInfo, &indexStats); err != nil {
+			return nil, err
+		}
+		indexStatsArray = append(indexStatsArray, indexStats)
+	}
+
+	return indexStatsArray, nil
+}
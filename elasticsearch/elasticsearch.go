@@ -2,43 +2,124 @@ package elasticsearch
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
-	"esmon/config"
 	"fmt"
-	"io"
-	"net/http"
 	"slices"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
+)
+
+const (
+	rootPath       = "/"
+	masterNodePath = "/_nodes/_master/http?filter_path=nodes.*.name"
+	nodesHttpPath  = "/_nodes/http?filter_path=nodes.*.http.publish_address"
+
+	clusterManagerNodePath = "/_nodes/_cluster_manager/http?filter_path=nodes.*.name"
+)
 
-	"golang.org/x/sync/errgroup"
+// The filter paths of the stats requests are derived from the structs the
+// responses are decoded into, a field added to a struct is requested
+// automatically. Node and index stats additionally select the index metrics.
+var (
+	clusterHealthPath = "/_cluster/health?human&filter_path=" + filterPath("", ClusterInfo{})
+	clusterStatsPath  = "/_cluster/stats?human&filter_path=" + filterPath("", ClusterStats{})
+	recoveryPath      = "/_recovery?active_only&human&filter_path=" + filterPath("*.shards", Recovery{})
+	pendingTasksPath  = "/_cluster/pending_tasks?human&filter_path=" + filterPath("tasks", PendingTask{})
+
+	// the columns of cat APIs are selected by name like filter paths
+	shardsPath      = "/_cat/shards?format=json&h=" + filterPath("", Shard{})
+	threadPoolsPath = "/_cat/thread_pool?format=json&h=" + filterPath("", ThreadPool{})
+
+	nodeStatsFilterPath  = filterPath("nodes.*", NodeStats{})
+	indexStatsFilterPath = filterPath("indices.*", IndexStats{})
 )
 
+// indexMetrics returns the index metrics decoded from node and index stats.
+// Requesting a metric unknown to the cluster fails the request.
+func indexMetrics(version Version) string {
+	metrics := "docs,store,indexing,search,get,refresh,merge"
+	if version.Supports(ShardStatsCapability) {
+		metrics += ",shard_stats"
+	}
+	return metrics
+}
+
+func nodeStatsPath(version Version) string {
+	return "/_nodes/stats/indices,os,fs,jvm,breaker/" + indexMetrics(version) + "?human&filter_path=" + nodeStatsFilterPath
+}
+
+func indexStatsPath(version Version) string {
+	return "/_stats/" + indexMetrics(version) + "?human&filter_path=" + indexStatsFilterPath
+}
+
+// DataSource identifies an API endpoint from which ClusterData is assembled.
+type DataSource string
+
 const (
-	clusterHealthPath = "/_cluster/health?human"
-	clusterStatsPath  = "/_cluster/stats?human"
-	shardStoresPath   = "/_shard_stores?status=all&human"
-	recoveryPath      = "/_recovery?active_only&human"
-	nodeStatsPath     = "/_nodes/stats/indices,os,fs?human"
-	indexStatsPath    = "/_stats?human"
-	masterNodePath    = "/_nodes/_master/stats/indices,os,fs?human"
+	VersionSource          DataSource = "/"
+	ClusterHealthSource    DataSource = "_cluster/health"
+	ClusterStatsSource     DataSource = "_cluster/stats"
+	ShardsSource           DataSource = "_cat/shards"
+	RecoveriesSource       DataSource = "_recovery"
+	NodeStatsSource        DataSource = "_nodes/stats"
+	IndexStatsSource       DataSource = "_stats"
+	MasterNodeSource       DataSource = "_nodes/_master"
+	PendingTasksSource     DataSource = "_cluster/pending_tasks"
+	ThreadPoolsSource      DataSource = "_cat/thread_pool"
+	ClusterSettingsSource  DataSource = "_cluster/settings"
+	SnapshotsSource        DataSource = "_snapshot"
+	LatestSnapshotSource   DataSource = "_snapshot/<repository>/_all"
+	SnapshotPoliciesSource DataSource = "_slm/policy"
+	IndexLifecycleSource   DataSource = "_ilm/explain" // _plugins/_ism/explain on OpenSearch
+	DataStreamsSource      DataSource = "_data_stream"
 )
 
-type Credentials struct {
-	Username string
-	Password string
+var DataSources = []DataSource{
+	VersionSource,
+	ClusterHealthSource,
+	ClusterStatsSource,
+	ShardsSource,
+	RecoveriesSource,
+	NodeStatsSource,
+	IndexStatsSource,
+	MasterNodeSource,
+	PendingTasksSource,
+	ThreadPoolsSource,
+	ClusterSettingsSource,
+	SnapshotsSource,
+	LatestSnapshotSource,
+	SnapshotPoliciesSource,
+	IndexLifecycleSource,
+	DataStreamsSource,
 }
 
 type ClusterData struct {
-	ClusterInfo  ClusterInfo
-	ClusterStats ClusterStats
-	ShardStores  []ShardStores
-	Recoveries   []Recovery
-	NodeStats    []NodeStats
-	IndexStats   []IndexStats
-	MasterNode   *NodeStats
+	Endpoint         string
+	Retries          int
+	FetchedAt        time.Time
+	Version          Version
+	ClusterInfo      ClusterInfo
+	ClusterStats     ClusterStats
+	Shards           []Shard
+	Recoveries       []Recovery
+	NodeStats        []NodeStats
+	IndexStats       []IndexStats
+	MasterNode       *NodeStats
+	PendingTasks     []PendingTask
+	ThreadPools      []ThreadPool
+	NodeRates        map[string]OperationRates
+	IndexRates       map[string]OperationRates
+	ClusterSettings  *ClusterSettings
+	Snapshots        Snapshots
+	LatestSnapshots  []Snapshot
+	SnapshotPolicies []SnapshotPolicy
+	IndexLifecycles  map[string]IndexLifecycle
+	DataStreams      []DataStream
+	Errors           map[DataSource]error
+	Updated          map[DataSource]time.Time
 }
 
 type ClusterInfo struct {
@@ -63,28 +144,71 @@ type ClusterInfo struct {
 type ClusterStats struct {
 	Indices struct {
 		Store struct {
-			Size                    string `json:"size"`
-			SizeInBytes             int    `json:"size_in_bytes"`
-			TotalDataSetSize        string `json:"total_data_set_size"`
-			TotalDataSetSizeInBytes int    `json:"total_data_set_size_in_bytes"`
-			Reserved                string `json:"reserved"`
-			ReservedInBytes         int    `json:"reserved_in_bytes"`
+			Size            string `json:"size"`
+			SizeInBytes     int    `json:"size_in_bytes"`
+			Reserved        string `json:"reserved"`
+			ReservedInBytes int    `json:"reserved_in_bytes"`
 		} `json:"store"`
 	} `json:"indices"`
 }
 
-// manually added while fetching
-type ShardStores struct {
-	Index  string
-	Shard  string
-	Stores []ShardStore
+const (
+	ShardStateStarted      = "STARTED"
+	ShardStateInitializing = "INITIALIZING"
+	ShardStateRelocating   = "RELOCATING"
+	ShardStateUnassigned   = "UNASSIGNED"
+)
+
+// Shard is a shard copy as listed by _cat/shards. Values which do not apply to
+// the state of the copy (e.g. the node of an unassigned copy) are empty.
+type Shard struct {
+	Index            string `json:"index"`
+	Shard            string `json:"shard"`
+	PriRep           string `json:"prirep"`
+	State            string `json:"state"`
+	Docs             string `json:"docs"`
+	Store            string `json:"store"`
+	Node             string `json:"node"`
+	UnassignedReason string `json:"unassigned.reason"`
+	UnassignedAt     string `json:"unassigned.at"`
+}
+
+func (s Shard) Primary() bool {
+	return s.PriRep == "p"
+}
+
+func (s Shard) ShardNumber() int {
+	number, _ := strconv.Atoi(s.Shard)
+	return number
+}
+
+// ThreadPool is a thread pool of a node as listed by _cat/thread_pool. The
+// queue size is empty for pools without a bounded queue.
+type ThreadPool struct {
+	NodeId    string `json:"node_id"`
+	NodeName  string `json:"node_name"`
+	Name      string `json:"name"`
+	Active    string `json:"active"`
+	Queue     string `json:"queue"`
+	QueueSize string `json:"queue_size"`
+	Rejected  string `json:"rejected"`
+	Completed string `json:"completed"`
 }
 
-type ShardStore struct {
-	Name       string
-	Allocation string
+// RejectedCount returns the number of tasks rejected since the node started.
+func (t ThreadPool) RejectedCount() int64 {
+	count, _ := strconv.ParseInt(t.Rejected, 10, 64)
+	return count
 }
 
+const (
+	RecoveryTypePeer          = "PEER"
+	RecoveryTypeSnapshot      = "SNAPSHOT"
+	RecoveryTypeExistingStore = "EXISTING_STORE"
+	RecoveryTypeEmptyStore    = "EMPTY_STORE"
+	RecoveryTypeLocalShards   = "LOCAL_SHARDS"
+)
+
 type Recovery struct {
 	ID                int          `json:"id"`
 	Type              string       `json:"type"`
@@ -132,25 +256,44 @@ type RecoveryPeerInterface interface {
 	PeerName() string
 }
 
+// RecoveryPeer is the source or target of a recovery. The source of a
+// snapshot restore is a repository, the source of a peer recovery is a node.
+// The peer is nil if the source names neither (e.g. for a recovery from the
+// local or an empty store).
 type RecoveryPeer struct {
 	Peer RecoveryPeerInterface
 }
 
 func (p *RecoveryPeer) UnmarshalJSON(data []byte) error {
-
-	var nodePeer NodePeer
-	if err := json.Unmarshal(data, &nodePeer); err == nil {
-		p.Peer = nodePeer
+	// every object decodes into both peers, a repository peer is recognized
+	// by its repository
+	var repositoryPeer RepositoryPeer
+	if err := json.Unmarshal(data, &repositoryPeer); err != nil {
+		return errors.New("Can not unmarshal RecoveryPeer")
+	}
+	if repositoryPeer.Repository != "" {
+		p.Peer = repositoryPeer
 		return nil
 	}
 
-	var repositoryPeer NodePeer
-	if err := json.Unmarshal(data, &nodePeer); err == nil {
-		p.Peer = repositoryPeer
+	var nodePeer NodePeer
+	if err := json.Unmarshal(data, &nodePeer); err != nil {
+		return errors.New("Can not unmarshal RecoveryPeer")
+	}
+	if nodePeer == (NodePeer{}) {
+		p.Peer = nil
 		return nil
 	}
+	p.Peer = nodePeer
+	return nil
+}
 
-	return errors.New("Can not unmarshl RecoveryPeer")
+// PeerName returns the name of the peer or an empty string if there is none.
+func (p RecoveryPeer) PeerName() string {
+	if p.Peer == nil {
+		return ""
+	}
+	return p.Peer.PeerName()
 }
 
 type NodePeer struct {
@@ -182,6 +325,15 @@ func (n RepositoryPeer) PeerName() string {
 	return n.Repository
 }
 
+type PendingTask struct {
+	InsertOrder       int    `json:"insert_order"`
+	Priority          string `json:"priority"`
+	Source            string `json:"source"`
+	Executing         bool   `json:"executing"`
+	TimeInQueue       string `json:"time_in_queue"`
+	TimeInQueueMillis int64  `json:"time_in_queue_millis"`
+}
+
 type NodeStats struct {
 	Timestamp        int64    `json:"timestamp"`
 	Id               string   // manually added while fetching
@@ -191,6 +343,7 @@ type NodeStats struct {
 	IP               string   `json:"ip"`
 	Roles            []string `json:"roles"`
 	Indices          struct {
+		OperationStats
 		Docs struct {
 			Count   int `json:"count"`
 			Deleted int `json:"deleted"`
@@ -199,12 +352,10 @@ type NodeStats struct {
 			TotalCount int `json:"total_count"`
 		} `json:"shard_stats"`
 		Store struct {
-			Size                    string `json:"size"`
-			SizeInBytes             int    `json:"size_in_bytes"`
-			TotalDataSetSize        string `json:"total_data_set_size"`
-			TotalDataSetSizeInBytes int    `json:"total_data_set_size_in_bytes"`
-			Reserved                string `json:"reserved"`
-			ReservedInBytes         int    `json:"reserved_in_bytes"`
+			Size            string `json:"size"`
+			SizeInBytes     int    `json:"size_in_bytes"`
+			Reserved        string `json:"reserved"`
+			ReservedInBytes int    `json:"reserved_in_bytes"`
 		} `json:"store"`
 	} `json:"indices"`
 	Os struct {
@@ -249,6 +400,24 @@ type NodeStats struct {
 			AvailableInBytes int64  `json:"available_in_bytes"`
 		} `json:"total"`
 	} `json:"fs"`
+	Jvm struct {
+		Mem struct {
+			HeapUsed        string `json:"heap_used"`
+			HeapUsedPercent int    `json:"heap_used_percent"`
+			HeapMax         string `json:"heap_max"`
+		} `json:"mem"`
+		Gc struct {
+			Collectors struct {
+				Old struct {
+					CollectionCount        int64 `json:"collection_count"`
+					CollectionTimeInMillis int64 `json:"collection_time_in_millis"`
+				} `json:"old"`
+			} `json:"collectors"`
+		} `json:"gc"`
+	} `json:"jvm"`
+	Breakers map[string]struct {
+		Tripped int64 `json:"tripped"`
+	} `json:"breakers"`
 }
 
 type IndexStats struct {
@@ -265,15 +434,14 @@ type IndexStats struct {
 			TotalCount int `json:"total_count"`
 		} `json:"shard_stats"`
 		Store struct {
-			Size                    string `json:"size"`
-			SizeInBytes             int    `json:"size_in_bytes"`
-			TotalDataSetSize        string `json:"total_data_set_size"`
-			TotalDataSetSizeInBytes int    `json:"total_data_set_size_in_bytes"`
-			Reserved                string `json:"reserved"`
-			ReservedInBytes         int    `json:"reserved_in_bytes"`
+			Size            string `json:"size"`
+			SizeInBytes     int    `json:"size_in_bytes"`
+			Reserved        string `json:"reserved"`
+			ReservedInBytes int    `json:"reserved_in_bytes"`
 		} `json:"store"`
 	} `json:"primaries"`
 	Total struct {
+		OperationStats
 		Docs struct {
 			Count   int `json:"count"`
 			Deleted int `json:"deleted"`
@@ -282,44 +450,72 @@ type IndexStats struct {
 			TotalCount int `json:"total_count"`
 		} `json:"shard_stats"`
 		Store struct {
-			Size                    string `json:"size"`
-			SizeInBytes             int    `json:"size_in_bytes"`
-			TotalDataSetSize        string `json:"total_data_set_size"`
-			TotalDataSetSizeInBytes int    `json:"total_data_set_size_in_bytes"`
-			Reserved                string `json:"reserved"`
-			ReservedInBytes         int    `json:"reserved_in_bytes"`
+			Size            string `json:"size"`
+			SizeInBytes     int    `json:"size_in_bytes"`
+			Reserved        string `json:"reserved"`
+			ReservedInBytes int    `json:"reserved_in_bytes"`
 		} `json:"store"`
 	} `json:"total"`
 }
 
-func GetCredentials(clusterConfig *config.ClusterConfig, defaultCredentials *Credentials) (*Credentials, error) {
-	credentials := Credentials{
-		Username: defaultCredentials.Username,
-		Password: defaultCredentials.Password,
+// FetchData queries the given data sources and the version concurrently. The
+// master node is looked up in the node stats, which are therefore fetched
+// along with it. A failing source does not discard the data of the other
+// sources; its error is recorded in ClusterData.Errors instead, successfully
+// fetched sources are recorded in ClusterData.Updated. An error is only
+// returned if every requested source failed, the version does not count as it
+// is usually served from the cache of the client.
+func (c *Client) FetchData(ctx context.Context, sources []DataSource) (*ClusterData, error) {
+	clusterData := ClusterData{
+		Errors:    make(map[DataSource]error),
+		Updated:   make(map[DataSource]time.Time),
+		FetchedAt: time.Now(),
 	}
 
-	if clusterConfig.Username != "" {
-		credentials.Username = clusterConfig.Username
+	if slices.Contains(sources, MasterNodeSource) && !slices.Contains(sources, NodeStatsSource) {
+		sources = append(slices.Clone(sources), NodeStatsSource)
 	}
 
-	if clusterConfig.Password != "" {
-		credentials.Password = clusterConfig.Password
-	}
+	retries := c.Retries()
+
+	c.discover(ctx)
 
-	if credentials.Username == "" || credentials.Password == "" {
-		return nil, errors.New("Neither cluster nor default credentials were provided.")
+	version, err := c.Version(ctx)
+	if err != nil {
+		clusterData.Errors[VersionSource] = err
+	} else {
+		clusterData.Version = *version
+		clusterData.Updated[VersionSource] = time.Now()
 	}
 
-	return &credentials, nil
-}
+	var (
+		waitGroup   sync.WaitGroup
+		resultMutex sync.Mutex
+	)
 
-func FetchData(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*ClusterData, error) {
-	clusterData := ClusterData{}
+	fetch := func(source DataSource, fetchFunc func() error) {
+		if !slices.Contains(sources, source) {
+			return
+		}
+
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := fetchFunc()
 
-	errorGroup := errgroup.Group{}
+			resultMutex.Lock()
+			defer resultMutex.Unlock()
 
-	errorGroup.Go(func() error {
-		clusterInfo, err := fetchClusterInfo(ctx, endpoint, credentials, timeoutSeconds, insecure)
+			if err != nil {
+				clusterData.Errors[source] = err
+			} else {
+				clusterData.Updated[source] = time.Now()
+			}
+		}()
+	}
+
+	fetch(ClusterHealthSource, func() error {
+		clusterInfo, err := c.fetchClusterInfo(ctx)
 		if err != nil {
 			return err
 		}
@@ -327,8 +523,8 @@ func FetchData(ctx context.Context, endpoint string, credentials *Credentials, t
 		return nil
 	})
 
-	errorGroup.Go(func() error {
-		clusterStats, err := fetchClusterStats(ctx, endpoint, credentials, timeoutSeconds, insecure)
+	fetch(ClusterStatsSource, func() error {
+		clusterStats, err := c.fetchClusterStats(ctx)
 		if err != nil {
 			return err
 		}
@@ -336,17 +532,17 @@ func FetchData(ctx context.Context, endpoint string, credentials *Credentials, t
 		return nil
 	})
 
-	errorGroup.Go(func() error {
-		shardStores, err := fetchShardStores(ctx, endpoint, credentials, timeoutSeconds, insecure)
+	fetch(ShardsSource, func() error {
+		shards, err := c.fetchShards(ctx)
 		if err != nil {
 			return err
 		}
-		clusterData.ShardStores = *shardStores
+		clusterData.Shards = *shards
 		return nil
 	})
 
-	errorGroup.Go(func() error {
-		recoveries, err := fetchRecoveries(ctx, endpoint, credentials, timeoutSeconds, insecure)
+	fetch(RecoveriesSource, func() error {
+		recoveries, err := c.fetchRecoveries(ctx)
 		if err != nil {
 			return err
 		}
@@ -354,8 +550,8 @@ func FetchData(ctx context.Context, endpoint string, credentials *Credentials, t
 		return nil
 	})
 
-	errorGroup.Go(func() error {
-		nodeStats, err := fetchNodeStats(ctx, endpoint, credentials, timeoutSeconds, insecure)
+	fetch(NodeStatsSource, func() error {
+		nodeStats, err := c.fetchNodeStats(ctx, clusterData.Version)
 		if err != nil {
 			return err
 		}
@@ -363,8 +559,8 @@ func FetchData(ctx context.Context, endpoint string, credentials *Credentials, t
 		return nil
 	})
 
-	errorGroup.Go(func() error {
-		indexStats, err := fetchIndexStats(ctx, endpoint, credentials, timeoutSeconds, insecure)
+	fetch(IndexStatsSource, func() error {
+		indexStats, err := c.fetchIndexStats(ctx, clusterData.Version)
 		if err != nil {
 			return err
 		}
@@ -372,9 +568,85 @@ func FetchData(ctx context.Context, endpoint string, credentials *Credentials, t
 		return nil
 	})
 
+	fetch(PendingTasksSource, func() error {
+		pendingTasks, err := c.fetchPendingTasks(ctx)
+		if err != nil {
+			return err
+		}
+		clusterData.PendingTasks = *pendingTasks
+		return nil
+	})
+
+	fetch(ThreadPoolsSource, func() error {
+		threadPools, err := c.fetchThreadPools(ctx)
+		if err != nil {
+			return err
+		}
+		clusterData.ThreadPools = *threadPools
+		return nil
+	})
+
+	fetch(ClusterSettingsSource, func() error {
+		clusterSettings, err := c.fetchClusterSettings(ctx)
+		if err != nil {
+			return err
+		}
+		clusterData.ClusterSettings = clusterSettings
+		return nil
+	})
+
+	fetch(SnapshotsSource, func() error {
+		snapshots, err := c.fetchSnapshots(ctx, clusterData.Version)
+		if err != nil {
+			return err
+		}
+		clusterData.Snapshots = *snapshots
+		return nil
+	})
+
+	fetch(LatestSnapshotSource, func() error {
+		latestSnapshots, err := c.fetchLatestSnapshot(ctx, clusterData.Version)
+		if err != nil {
+			return err
+		}
+		clusterData.LatestSnapshots = *latestSnapshots
+		return nil
+	})
+
+	// clusters without snapshot lifecycle management have no policies
+	fetch(SnapshotPoliciesSource, func() error {
+		if !clusterData.Version.Supports(SnapshotLifecycleCapability) {
+			return nil
+		}
+		snapshotPolicies, err := c.fetchSnapshotPolicies(ctx)
+		if err != nil {
+			return err
+		}
+		clusterData.SnapshotPolicies = *snapshotPolicies
+		return nil
+	})
+
+	fetch(IndexLifecycleSource, func() error {
+		indexLifecycles, err := c.fetchIndexLifecycles(ctx, clusterData.Version)
+		if err != nil {
+			return err
+		}
+		clusterData.IndexLifecycles = indexLifecycles
+		return nil
+	})
+
+	fetch(DataStreamsSource, func() error {
+		dataStreams, err := c.fetchDataStreams(ctx, clusterData.Version)
+		if err != nil {
+			return err
+		}
+		clusterData.DataStreams = *dataStreams
+		return nil
+	})
+
 	var masterNodeId string
-	errorGroup.Go(func() error {
-		masterNodeIdValue, err := fetchMasterNodeId(ctx, endpoint, credentials, timeoutSeconds, insecure)
+	fetch(MasterNodeSource, func() error {
+		masterNodeIdValue, err := c.fetchMasterNodeId(ctx, clusterData.Version)
 		if err != nil {
 			return err
 		}
@@ -382,21 +654,48 @@ func FetchData(ctx context.Context, endpoint string, credentials *Credentials, t
 		return nil
 	})
 
-	if err := errorGroup.Wait(); err != nil {
-		return nil, err
+	waitGroup.Wait()
+
+	if len(sources) > 0 && !slices.ContainsFunc(sources, func(source DataSource) bool {
+		_, updated := clusterData.Updated[source]
+		return updated
+	}) {
+		if err := clusterData.Errors[ClusterHealthSource]; err != nil {
+			return nil, err
+		}
+		return nil, clusterData.Errors[clusterData.FailedSources()[0]]
 	}
 
-	sort.Slice(clusterData.ShardStores, func(i, j int) bool {
-		if clusterData.ShardStores[i].Index == clusterData.ShardStores[j].Index {
-			return clusterData.ShardStores[i].Shard < clusterData.ShardStores[j].Shard
+	clusterData.Endpoint = c.CurrentEndpoint()
+	clusterData.Retries = int(c.Retries() - retries)
+
+	sort.SliceStable(clusterData.Shards, func(i, j int) bool {
+		a, b := clusterData.Shards[i], clusterData.Shards[j]
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+		if a.Shard != b.Shard {
+			return a.ShardNumber() < b.ShardNumber()
 		}
-		return clusterData.ShardStores[i].Index < clusterData.ShardStores[j].Index
+		return a.Primary() && !b.Primary()
 	})
 
 	sort.Slice(clusterData.Recoveries, func(i, j int) bool {
 		return clusterData.Recoveries[i].TotalTimeInMillis > clusterData.Recoveries[j].TotalTimeInMillis
 	})
 
+	sort.Slice(clusterData.PendingTasks, func(i, j int) bool {
+		return clusterData.PendingTasks[i].TimeInQueueMillis > clusterData.PendingTasks[j].TimeInQueueMillis
+	})
+
+	sort.SliceStable(clusterData.ThreadPools, func(i, j int) bool {
+		a, b := clusterData.ThreadPools[i], clusterData.ThreadPools[j]
+		if a.NodeName != b.NodeName {
+			return a.NodeName < b.NodeName
+		}
+		return a.Name < b.Name
+	})
+
 	sort.Slice(clusterData.NodeStats, func(i, j int) bool {
 		return clusterData.NodeStats[i].Name < clusterData.NodeStats[j].Name
 	})
@@ -405,46 +704,101 @@ func FetchData(ctx context.Context, endpoint string, credentials *Credentials, t
 		return clusterData.IndexStats[i].Total.Store.SizeInBytes > clusterData.IndexStats[j].Total.Store.SizeInBytes
 	})
 
-	index := slices.IndexFunc(
-		clusterData.NodeStats,
-		func(s NodeStats) bool {
-			return s.Id == masterNodeId
-		})
-
-	if index == -1 {
-		return nil, errors.New(fmt.Sprintf("Unable to find master node with ID %s in node list", masterNodeId))
+	if slices.Contains(sources, MasterNodeSource) && clusterData.Errors[MasterNodeSource] == nil && clusterData.Errors[NodeStatsSource] == nil {
+		index := slices.IndexFunc(
+			clusterData.NodeStats,
+			func(s NodeStats) bool {
+				return s.Id == masterNodeId
+			})
+
+		if index == -1 {
+			clusterData.Errors[MasterNodeSource] = errors.New(fmt.Sprintf("Unable to find master node with ID %s in node list", masterNodeId))
+			delete(clusterData.Updated, MasterNodeSource)
+		} else {
+			clusterData.MasterNode = &clusterData.NodeStats[index]
+		}
 	}
 
-	clusterData.MasterNode = &clusterData.NodeStats[index]
-
 	return &clusterData, nil
 }
 
-func httpClient(timeoutSeconds uint, insecure bool) http.Client {
-	customTransport := http.DefaultTransport.(*http.Transport).Clone()
-	customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecure}
-	httpClient := http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second, Transport: customTransport}
-
-	return httpClient
-}
-
-func fetchClusterInfo(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*ClusterInfo, error) {
-	httpClient := httpClient(timeoutSeconds, insecure)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+clusterHealthPath, nil)
-	if err != nil {
-		return nil, err
+// Merge copies the data sources fetched in update into the cluster data. The
+// data and errors of sources which were not fetched in update are kept. The
+// operation rates are computed from the replaced node and index stats.
+func (d *ClusterData) Merge(update *ClusterData) {
+	d.Endpoint = update.Endpoint
+	d.Retries = update.Retries
+	d.FetchedAt = update.FetchedAt
+
+	for source, err := range update.Errors {
+		d.Errors[source] = err
+	}
+
+	for source, updated := range update.Updated {
+		previousUpdate := d.Updated[source]
+		delete(d.Errors, source)
+		d.Updated[source] = updated
+
+		switch source {
+		case VersionSource:
+			d.Version = update.Version
+		case ClusterHealthSource:
+			d.ClusterInfo = update.ClusterInfo
+		case ClusterStatsSource:
+			d.ClusterStats = update.ClusterStats
+		case ShardsSource:
+			d.Shards = update.Shards
+		case RecoveriesSource:
+			d.Recoveries = update.Recoveries
+		case NodeStatsSource:
+			d.NodeRates = nodeRates(d.NodeStats, update.NodeStats)
+			d.NodeStats = update.NodeStats
+		case IndexStatsSource:
+			d.IndexRates = indexRates(d.IndexStats, update.IndexStats, updated.Sub(previousUpdate))
+			d.IndexStats = update.IndexStats
+		case MasterNodeSource:
+			d.MasterNode = update.MasterNode
+		case PendingTasksSource:
+			d.PendingTasks = update.PendingTasks
+		case ThreadPoolsSource:
+			d.ThreadPools = update.ThreadPools
+		case ClusterSettingsSource:
+			d.ClusterSettings = update.ClusterSettings
+		case SnapshotsSource:
+			d.Snapshots = update.Snapshots
+		case LatestSnapshotSource:
+			d.LatestSnapshots = update.LatestSnapshots
+		case SnapshotPoliciesSource:
+			d.SnapshotPolicies = update.SnapshotPolicies
+		case IndexLifecycleSource:
+			d.IndexLifecycles = update.IndexLifecycles
+		case DataStreamsSource:
+			d.DataStreams = update.DataStreams
+		}
 	}
+}
 
-	req.SetBasicAuth(credentials.Username, credentials.Password)
+// Fetched reports whether the source was fetched, successfully or not.
+func (d *ClusterData) Fetched(source DataSource) bool {
+	_, updated := d.Updated[source]
+	_, failed := d.Errors[source]
+	return updated || failed
+}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
+// FailedSources returns the data sources that could not be fetched in the
+// order of DataSources.
+func (d *ClusterData) FailedSources() []DataSource {
+	var failedSources []DataSource
+	for _, source := range DataSources {
+		if d.Errors[source] != nil {
+			failedSources = append(failedSources, source)
+		}
 	}
-	defer resp.Body.Close()
+	return failedSources
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) fetchClusterInfo(ctx context.Context) (*ClusterInfo, error) {
+	body, err := c.get(ctx, clusterHealthPath)
 	if err != nil {
 		return nil, err
 	}
@@ -458,23 +812,8 @@ func fetchClusterInfo(ctx context.Context, endpoint string, credentials *Credent
 	return &clusterInfo, nil
 }
 
-func fetchClusterStats(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*ClusterStats, error) {
-	httpClient := httpClient(timeoutSeconds, insecure)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+clusterStatsPath, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.SetBasicAuth(credentials.Username, credentials.Password)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) fetchClusterStats(ctx context.Context) (*ClusterStats, error) {
+	body, err := c.get(ctx, clusterStatsPath)
 	if err != nil {
 		return nil, err
 	}
@@ -487,119 +826,52 @@ func fetchClusterStats(ctx context.Context, endpoint string, credentials *Creden
 	return &clusterStats, nil
 }
 
-// TODO: refactor to make this somehow more readable and cleaner?
-func fetchShardStores(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*[]ShardStores, error) {
-	httpClient := httpClient(timeoutSeconds, insecure)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+shardStoresPath, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.SetBasicAuth(credentials.Username, credentials.Password)
+func (c *Client) fetchShards(ctx context.Context) (*[]Shard, error) {
+	var shards []Shard
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	err := c.decode(ctx, shardsPath, func(decoder *json.Decoder) error {
+		shards = nil
 
-	body, err := io.ReadAll(resp.Body)
+		return decodeArray(decoder, func() error {
+			var shard Shard
+			if err := decoder.Decode(&shard); err != nil {
+				return err
+			}
+			shards = append(shards, shard)
+			return nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var rawMap map[string]json.RawMessage
-	if err = json.Unmarshal(body, &rawMap); err != nil {
-		return nil, err
-	}
-
-	var indexMap map[string]json.RawMessage
-	if err = json.Unmarshal(rawMap["indices"], &indexMap); err != nil {
-		return nil, err
-	}
+	return &shards, nil
+}
 
-	var shardStoresArray []ShardStores
+func (c *Client) fetchThreadPools(ctx context.Context) (*[]ThreadPool, error) {
+	var threadPools []ThreadPool
 
-	for index, indexInfo := range indexMap {
-		var shardStores ShardStores = ShardStores{
-			Index: index,
-		}
+	err := c.decode(ctx, threadPoolsPath, func(decoder *json.Decoder) error {
+		threadPools = nil
 
-		var shardWrapperMap map[string]json.RawMessage
-		if err = json.Unmarshal(indexInfo, &shardWrapperMap); err != nil {
-			return nil, err
-		}
-
-		for _, shardWrapperInfo := range shardWrapperMap {
-			var shardMap map[string]json.RawMessage
-			if err = json.Unmarshal(shardWrapperInfo, &shardMap); err != nil {
-				return nil, err
+		return decodeArray(decoder, func() error {
+			var threadPool ThreadPool
+			if err := decoder.Decode(&threadPool); err != nil {
+				return err
 			}
-
-			for shard, shardInfo := range shardMap {
-				shardStores.Shard = shard
-
-				var storeWrapperMap map[string]json.RawMessage
-				if err = json.Unmarshal(shardInfo, &storeWrapperMap); err != nil {
-					return nil, err
-				}
-
-				for _, storesInfo := range storeWrapperMap {
-					var storesMap []map[string]json.RawMessage
-					if err = json.Unmarshal(storesInfo, &storesMap); err != nil {
-						return nil, err
-					}
-
-					for _, storeDetails := range storesMap {
-						var shardStore ShardStore = ShardStore{}
-
-						if err = json.Unmarshal(storeDetails["allocation"], &shardStore.Allocation); err != nil {
-							return nil, err
-						}
-
-						for key, storeDetailsMap := range storeDetails {
-							if key != "allocation" && key != "allocation_id" {
-								var storeMap map[string]json.RawMessage
-								if err = json.Unmarshal(storeDetailsMap, &storeMap); err != nil {
-									return nil, err
-								}
-
-								if err = json.Unmarshal(storeMap["name"], &shardStore.Name); err != nil {
-									return nil, err
-								}
-
-								shardStores.Stores = append(shardStores.Stores, shardStore)
-							}
-						}
-					}
-				}
-			}
-		}
-
-		shardStoresArray = append(shardStoresArray, shardStores)
-	}
-
-	return &shardStoresArray, nil
-}
-
-func fetchRecoveries(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*[]Recovery, error) {
-	httpClient := httpClient(timeoutSeconds, insecure)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+recoveryPath, nil)
+			threadPools = append(threadPools, threadPool)
+			return nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	req.SetBasicAuth(credentials.Username, credentials.Password)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return &threadPools, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) fetchRecoveries(ctx context.Context) (*[]Recovery, error) {
+	body, err := c.get(ctx, recoveryPath)
 	if err != nil {
 		return nil, err
 	}
@@ -623,77 +895,64 @@ func fetchRecoveries(ctx context.Context, endpoint string, credentials *Credenti
 
 		for _, recovery := range indexRecoveries {
 			recovery.Index.Name = index
-
-			if recovery.Type == "PEER" {
-				recoveries = append(recoveries, recovery)
-			}
+			recoveries = append(recoveries, recovery)
 		}
 	}
 
 	return &recoveries, nil
 }
 
-func fetchNodeStats(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*[]NodeStats, error) {
-	httpClient := httpClient(timeoutSeconds, insecure)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+nodeStatsPath, nil)
+func (c *Client) fetchPendingTasks(ctx context.Context) (*[]PendingTask, error) {
+	body, err := c.get(ctx, pendingTasksPath)
 	if err != nil {
 		return nil, err
 	}
 
-	req.SetBasicAuth(credentials.Username, credentials.Password)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	var pendingTasks struct {
+		Tasks []PendingTask `json:"tasks"`
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var rawMap map[string]json.RawMessage
-	if err = json.Unmarshal(body, &rawMap); err != nil {
+	if err = json.Unmarshal(body, &pendingTasks); err != nil {
 		return nil, err
 	}
 
-	var nodeInfos map[string]json.RawMessage
-	if err = json.Unmarshal(rawMap["nodes"], &nodeInfos); err != nil {
-		return nil, err
-	}
+	return &pendingTasks.Tasks, nil
+}
 
+func (c *Client) fetchNodeStats(ctx context.Context, version Version) (*[]NodeStats, error) {
 	var nodeStatsArray []NodeStats
-	for id, nodeInfo := range nodeInfos {
-		var nodeStats NodeStats
-		nodeStats.Id = id
-		if err = json.Unmarshal(nodeInfo, &nodeStats); err != nil {
-			return nil, err
-		}
-		nodeStatsArray = append(nodeStatsArray, nodeStats)
-	}
 
-	return &nodeStatsArray, nil
-}
+	err := c.decode(ctx, nodeStatsPath(version), func(decoder *json.Decoder) error {
+		nodeStatsArray = nil
 
-func fetchMasterNodeId(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*string, error) {
-	httpClient := httpClient(timeoutSeconds, insecure)
+		return decodeObject(decoder, func(key string) error {
+			if key != "nodes" {
+				return skipValue(decoder)
+			}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+masterNodePath, nil)
+			return decodeObject(decoder, func(id string) error {
+				nodeStats := NodeStats{Id: id}
+				if err := decoder.Decode(&nodeStats); err != nil {
+					return err
+				}
+				nodeStatsArray = append(nodeStatsArray, nodeStats)
+				return nil
+			})
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	req.SetBasicAuth(credentials.Username, credentials.Password)
+	return &nodeStatsArray, nil
+}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
+func (c *Client) fetchMasterNodeId(ctx context.Context, version Version) (*string, error) {
+	path := masterNodePath
+	if version.Supports(ClusterManagerCapability) {
+		path = clusterManagerNodePath
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -720,46 +979,30 @@ func fetchMasterNodeId(ctx context.Context, endpoint string, credentials *Creden
 	return &masterNodeId, nil
 }
 
-func fetchIndexStats(ctx context.Context, endpoint string, credentials *Credentials, timeoutSeconds uint, insecure bool) (*[]IndexStats, error) {
-	httpClient := httpClient(timeoutSeconds, insecure)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+indexStatsPath, nil)
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) fetchIndexStats(ctx context.Context, version Version) (*[]IndexStats, error) {
+	var indexStatsArray []IndexStats
 
-	req.SetBasicAuth(credentials.Username, credentials.Password)
+	err := c.decode(ctx, indexStatsPath(version), func(decoder *json.Decoder) error {
+		indexStatsArray = nil
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+		return decodeObject(decoder, func(key string) error {
+			if key != "indices" {
+				return skipValue(decoder)
+			}
 
-	body, err := io.ReadAll(resp.Body)
+			return decodeObject(decoder, func(name string) error {
+				indexStats := IndexStats{Name: name}
+				if err := decoder.Decode(&indexStats); err != nil {
+					return err
+				}
+				indexStatsArray = append(indexStatsArray, indexStats)
+				return nil
+			})
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var rawMap map[string]json.RawMessage
-	if err = json.Unmarshal(body, &rawMap); err != nil {
-		return nil, err
-	}
-
-	var indexInfos map[string]json.RawMessage
-	if err = json.Unmarshal(rawMap["indices"], &indexInfos); err != nil {
-		return nil, err
-	}
-
-	var indexStatsArray []IndexStats
-	for name, indexInfo := range indexInfos {
-		var indexStats IndexStats
-		indexStats.Name = name
-		if err = json.Unmarshal(indexInfo, &indexStats); err != nil {
-			return nil, err
-		}
-		indexStatsArray = append(indexStatsArray, indexStats)
-	}
-
 	return &indexStatsArray, nil
 }
@@ -0,0 +1,74 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"esmon/config"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestClient returns a client sending its requests to the handler.
+func newTestClient(t testing.TB, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(
+		&config.ClusterConfig{Alias: "test", Endpoint: server.URL},
+		&Credentials{},
+		config.HttpConfig{Timeout: 5, Retry: config.RetryConfig{MaxAttempts: 1}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	return client
+}
+
+func TestFetchDataFailsIfEverySourceFails(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == rootPath:
+			w.Write([]byte(`{"version":{"number":"8.12.0"}}`))
+		case healthy.Load() && r.URL.Path == "/_cluster/health":
+			w.Write([]byte(`{"cluster_name":"test","status":"green"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":{"type":"test_exception","reason":"unavailable"},"status":500}`))
+		}
+	})
+
+	sources := []DataSource{ClusterHealthSource, PendingTasksSource}
+
+	clusterData, err := client.FetchData(context.Background(), sources)
+	if err != nil {
+		t.Fatalf("expected partial data, got error %s", err)
+	}
+	if _, updated := clusterData.Updated[ClusterHealthSource]; !updated {
+		t.Errorf("expected %s to be updated", ClusterHealthSource)
+	}
+	if clusterData.Errors[PendingTasksSource] == nil {
+		t.Errorf("expected an error for %s", PendingTasksSource)
+	}
+
+	// the version is cached now, it must not hide the failure of the sources
+	healthy.Store(false)
+
+	clusterData, err = client.FetchData(context.Background(), sources)
+	if err == nil {
+		t.Fatalf("expected an error, got data updated at %v", clusterData.Updated)
+	}
+
+	var apiError *APIError
+	if !errors.As(err, &apiError) || apiError.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected the API error of the cluster health, got %v", err)
+	}
+}
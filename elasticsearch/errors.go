@@ -0,0 +1,115 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// responses which are not Elasticsearch errors (e.g. HTML pages of a
+	// proxy) are truncated when used as reason
+	maxRawReasonLength = 200
+)
+
+// APIError is returned for responses with a non-successful status code.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Reason     string
+	RootCauses []APIErrorCause
+}
+
+type APIErrorCause struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type apiErrorResponse struct {
+	Error  json.RawMessage `json:"error"`
+	Status int             `json:"status"`
+}
+
+func (e *APIError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Reason)
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Type, e.Reason)
+}
+
+// newAPIError parses the error response body. Elasticsearch returns the error
+// either as object or, for some endpoints, as plain string.
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiError := APIError{
+		StatusCode: statusCode,
+		Reason:     http.StatusText(statusCode),
+	}
+
+	var response apiErrorResponse
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Error) == 0 {
+		if reason := strings.TrimSpace(string(body)); reason != "" {
+			if runes := []rune(reason); len(runes) > maxRawReasonLength {
+				reason = string(runes[:maxRawReasonLength]) + "…"
+			}
+			apiError.Reason = reason
+		}
+		return &apiError
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(response.Error), []byte(`"`)) {
+		json.Unmarshal(response.Error, &apiError.Reason)
+		return &apiError
+	}
+
+	var errorObject struct {
+		Type      string          `json:"type"`
+		Reason    string          `json:"reason"`
+		RootCause []APIErrorCause `json:"root_cause"`
+	}
+	if err := json.Unmarshal(response.Error, &errorObject); err == nil {
+		apiError.Type = errorObject.Type
+		apiError.Reason = errorObject.Reason
+		apiError.RootCauses = errorObject.RootCause
+	}
+
+	return &apiError
+}
+
+// isUnsupportedEndpoint reports whether the request failed because the cluster
+// does not provide the endpoint, e.g. an X-Pack API on the OSS distribution.
+// Depending on the version, such a request is rejected as a path without
+// handler, as not found without an Elasticsearch error or, since the path
+// starts with an underscore, as an invalid index name. Other errors (e.g. a
+// missing index, invalid parameters or missing privileges) are not caused by
+// a missing endpoint.
+func isUnsupportedEndpoint(err error) bool {
+	var apiError *APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+
+	if apiError.StatusCode != http.StatusBadRequest && apiError.StatusCode != http.StatusNotFound {
+		return false
+	}
+	if apiError.StatusCode == http.StatusNotFound && apiError.Type == "" && len(apiError.RootCauses) == 0 {
+		return true
+	}
+
+	causes := append([]APIErrorCause{{Type: apiError.Type, Reason: apiError.Reason}}, apiError.RootCauses...)
+	return slices.ContainsFunc(causes, isUnsupportedEndpointCause)
+}
+
+func isUnsupportedEndpointCause(cause APIErrorCause) bool {
+	switch cause.Type {
+	case "invalid_index_name_exception":
+		return true
+	// older versions return the error as plain string, which has no type
+	case "illegal_argument_exception", "":
+		return strings.Contains(cause.Reason, "no handler found")
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package elasticsearch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIsUnsupportedEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       bool
+	}{
+		{
+			name:       "not found without error",
+			statusCode: http.StatusNotFound,
+			body:       `<html><body>404 Not Found</body></html>`,
+			want:       true,
+		},
+		{
+			name:       "not found without handler",
+			statusCode: http.StatusNotFound,
+			body:       `{"error":"no handler found for uri [/_data_stream] and method [GET]"}`,
+			want:       true,
+		},
+		{
+			name:       "missing index",
+			statusCode: http.StatusNotFound,
+			body: `{"error":{"root_cause":[{"type":"index_not_found_exception","reason":"no such index [logs]"}],` +
+				`"type":"index_not_found_exception","reason":"no such index [logs]"},"status":404}`,
+		},
+		{
+			name:       "missing repository",
+			statusCode: http.StatusNotFound,
+			body: `{"error":{"root_cause":[{"type":"repository_missing_exception","reason":"[backups] missing"}],` +
+				`"type":"repository_missing_exception","reason":"[backups] missing"},"status":404}`,
+		},
+		{
+			name:       "no handler as string",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":"no handler found for uri [/_slm/policy] and method [GET]"}`,
+			want:       true,
+		},
+		{
+			name:       "no handler as object",
+			statusCode: http.StatusBadRequest,
+			body: `{"error":{"root_cause":[{"type":"illegal_argument_exception","reason":"no handler found for uri [/_slm/policy] and method [GET]"}],` +
+				`"type":"illegal_argument_exception","reason":"no handler found for uri [/_slm/policy] and method [GET]"},"status":400}`,
+			want: true,
+		},
+		{
+			name:       "invalid index name",
+			statusCode: http.StatusBadRequest,
+			body: `{"error":{"root_cause":[{"type":"invalid_index_name_exception","reason":"Invalid index name [_slm], must not start with '_'."}],` +
+				`"type":"invalid_index_name_exception","reason":"Invalid index name [_slm], must not start with '_'."},"status":400}`,
+			want: true,
+		},
+		{
+			name:       "invalid parameter",
+			statusCode: http.StatusBadRequest,
+			body: `{"error":{"root_cause":[{"type":"illegal_argument_exception","reason":"request [/_data_stream] contains unrecognized parameter: [expand_wildcards]"}],` +
+				`"type":"illegal_argument_exception","reason":"request [/_data_stream] contains unrecognized parameter: [expand_wildcards]"},"status":400}`,
+		},
+		{
+			name:       "security exception",
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"type":"security_exception","reason":"missing authentication credentials"},"status":400}`,
+		},
+		{
+			name:       "forbidden",
+			statusCode: http.StatusForbidden,
+			body:       `{"error":{"type":"security_exception","reason":"action [cluster:admin/slm/get] is unauthorized"},"status":403}`,
+		},
+		{
+			name:       "server error",
+			statusCode: http.StatusInternalServerError,
+			body:       `{"error":{"type":"illegal_argument_exception","reason":"no handler found"},"status":500}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := fmt.Errorf("request failed: %w", newAPIError(test.statusCode, []byte(test.body)))
+			if got := isUnsupportedEndpoint(err); got != test.want {
+				t.Errorf("got %v, want %v for %s", got, test.want, err)
+			}
+		})
+	}
+
+	if isUnsupportedEndpoint(errors.New("connection refused")) {
+		t.Error("expected errors without response to be supported")
+	}
+}
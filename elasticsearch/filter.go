@@ -0,0 +1,56 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// filterPath returns the filter_path parameter value selecting every field the
+// value decodes, so a response only contains what its struct uses. Fields
+// without a json tag are set while fetching and skipped, unless they are
+// embedded structs whose fields are decoded in place. The prefix addresses
+// the value within the response, e.g. nodes.* for the entries of a node map.
+// The entries of maps are selected by a wildcard. Types which decode
+// themselves (json.Unmarshaler) are selected as a whole.
+func filterPath(prefix string, value any) string {
+	return strings.Join(filterPaths(prefix, reflect.TypeOf(value)), ",")
+}
+
+func filterPaths(prefix string, t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Map {
+		return filterPaths(prefix+".*", t.Elem())
+	}
+
+	if t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return []string{prefix}
+	}
+
+	var paths []string
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" && field.Anonymous {
+			paths = append(paths, filterPaths(prefix, field.Type)...)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		paths = append(paths, filterPaths(name, field.Type)...)
+	}
+
+	return paths
+}
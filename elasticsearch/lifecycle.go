@@ -0,0 +1,202 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ilmErrorStep is the step of an index whose ILM policy failed to execute a
+// step. The failed step is reported separately.
+const ilmErrorStep = "ERROR"
+
+var (
+	ilmExplainFilterPath = filterPath("indices.*", ilmExplain{})
+	ismExplainPath       = "/_plugins/_ism/explain?filter_path=" + filterPath("*", ismExplain{})
+)
+
+// ilmExplainPath returns the path explaining the lifecycle of every index.
+// Hidden indices (e.g. the backing indices of data streams) are included
+// where the cluster knows them.
+func ilmExplainPath(version Version) string {
+	path := "/_all/_ilm/explain?human&filter_path=" + ilmExplainFilterPath
+	if version.Supports(HiddenIndicesCapability) {
+		path += "&expand_wildcards=open,hidden"
+	}
+	return path
+}
+
+// ilmExplain is an index of the _ilm/explain response.
+type ilmExplain struct {
+	Managed    bool   `json:"managed"`
+	Policy     string `json:"policy"`
+	Phase      string `json:"phase"`
+	Action     string `json:"action"`
+	Step       string `json:"step"`
+	FailedStep string `json:"failed_step"`
+	Age        string `json:"age"`
+	// the structure of the step info depends on the step
+	StepInfo       json.RawMessage `json:"step_info"`
+	PhaseExecution struct {
+		PhaseDefinition json.RawMessage `json:"phase_definition"`
+	} `json:"phase_execution"`
+}
+
+// ismExplain is an index of the _plugins/_ism/explain response. The times
+// are epoch milliseconds.
+type ismExplain struct {
+	PolicyId string `json:"policy_id"`
+	State    struct {
+		Name      string `json:"name"`
+		StartTime int64  `json:"start_time"`
+	} `json:"state"`
+	Action struct {
+		Name   string `json:"name"`
+		Failed bool   `json:"failed"`
+	} `json:"action"`
+	Step struct {
+		Name       string `json:"name"`
+		StepStatus string `json:"step_status"`
+	} `json:"step"`
+	RetryInfo struct {
+		Failed bool `json:"failed"`
+	} `json:"retry_info"`
+	// the structure of the info depends on the step, it usually contains a
+	// message
+	Info json.RawMessage `json:"info"`
+}
+
+// IndexLifecycle is the state of an index managed by an ILM policy or, on
+// OpenSearch, an ISM policy. ISM states are reported as phases.
+type IndexLifecycle struct {
+	Index  string
+	Policy string
+	Phase  string
+	Action string
+	Step   string
+	// Failed is set if the index is stuck in a failed step, FailedStep is the
+	// step which failed
+	Failed     bool
+	FailedStep string
+	// Age is the time since the lifecycle date for ILM and the time in the
+	// current state for ISM
+	Age string
+	// StepInfo describes the failure or the progress of the step
+	StepInfo json.RawMessage
+	// PhaseDefinition is the definition of the current phase in the ILM
+	// policy, it is empty for ISM
+	PhaseDefinition json.RawMessage
+}
+
+// fetchIndexLifecycles returns the lifecycle state of the managed indices by
+// index name. Clusters without ILM or ISM have no managed indices.
+func (c *Client) fetchIndexLifecycles(ctx context.Context, version Version) (map[string]IndexLifecycle, error) {
+	lifecycles := make(map[string]IndexLifecycle)
+
+	var err error
+	switch {
+	case version.Supports(IndexLifecycleCapability):
+		err = c.decode(ctx, ilmExplainPath(version), func(decoder *json.Decoder) error {
+			clear(lifecycles)
+
+			return decodeObject(decoder, func(key string) error {
+				if key != "indices" {
+					return skipValue(decoder)
+				}
+
+				return decodeObject(decoder, func(index string) error {
+					var explain ilmExplain
+					if err := decoder.Decode(&explain); err != nil {
+						return err
+					}
+					if explain.Managed {
+						lifecycles[index] = ilmLifecycle(index, explain)
+					}
+					return nil
+				})
+			})
+		})
+
+	case version.Supports(IndexStateManagementCapability):
+		err = c.decode(ctx, ismExplainPath, func(decoder *json.Decoder) error {
+			clear(lifecycles)
+
+			// besides the indices, the response contains the number of
+			// managed indices
+			return decodeObject(decoder, func(index string) error {
+				if index == "total_managed_indices" {
+					return skipValue(decoder)
+				}
+
+				var explain ismExplain
+				if err := decoder.Decode(&explain); err != nil {
+					return err
+				}
+				if explain.PolicyId != "" {
+					lifecycles[index] = ismLifecycle(index, explain)
+				}
+				return nil
+			})
+		})
+	}
+
+	if err != nil {
+		if isUnsupportedEndpoint(err) {
+			return map[string]IndexLifecycle{}, nil
+		}
+		return nil, err
+	}
+
+	return lifecycles, nil
+}
+
+func ilmLifecycle(index string, explain ilmExplain) IndexLifecycle {
+	return IndexLifecycle{
+		Index:           index,
+		Policy:          explain.Policy,
+		Phase:           explain.Phase,
+		Action:          explain.Action,
+		Step:            explain.Step,
+		Failed:          explain.Step == ilmErrorStep,
+		FailedStep:      explain.FailedStep,
+		Age:             explain.Age,
+		StepInfo:        explain.StepInfo,
+		PhaseDefinition: explain.PhaseExecution.PhaseDefinition,
+	}
+}
+
+func ismLifecycle(index string, explain ismExplain) IndexLifecycle {
+	lifecycle := IndexLifecycle{
+		Index:    index,
+		Policy:   explain.PolicyId,
+		Phase:    explain.State.Name,
+		Action:   explain.Action.Name,
+		Step:     explain.Step.Name,
+		Failed:   explain.Action.Failed || explain.RetryInfo.Failed || explain.Step.StepStatus == "failed",
+		StepInfo: explain.Info,
+	}
+
+	if lifecycle.Failed {
+		lifecycle.FailedStep = explain.Step.Name
+	}
+	if explain.State.StartTime > 0 {
+		lifecycle.Age = humanDuration(time.Since(time.UnixMilli(explain.State.StartTime)))
+	}
+
+	return lifecycle
+}
+
+// humanDuration formats a duration in its largest unit like the human readable
+// times of Elasticsearch (e.g. 3.2d).
+func humanDuration(duration time.Duration) string {
+	switch {
+	case duration >= 24*time.Hour:
+		return fmt.Sprintf("%.1fd", duration.Hours()/24)
+	case duration >= time.Hour:
+		return fmt.Sprintf("%.1fh", duration.Hours())
+	case duration >= time.Minute:
+		return fmt.Sprintf("%.1fm", duration.Minutes())
+	}
+	return fmt.Sprintf("%.0fs", duration.Seconds())
+}
@@ -0,0 +1,118 @@
+package elasticsearch
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// a dead endpoint is not used until its timeout expires, the timeout
+	// doubles with every consecutive failure up to the maximum
+	deadEndpointTimeout    = 5 * time.Second
+	deadEndpointMaxTimeout = 2 * time.Minute
+)
+
+type endpoint struct {
+	url       string
+	failures  int
+	deadUntil time.Time
+}
+
+// endpointPool selects the endpoint for each request. Without round-robin, the
+// current endpoint is used until it fails. With round-robin, the alive
+// endpoints are used in turn.
+type endpointPool struct {
+	mutex      sync.Mutex
+	endpoints  []*endpoint
+	current    int
+	roundRobin bool
+}
+
+func newEndpointPool(urls []string, roundRobin bool) *endpointPool {
+	pool := endpointPool{roundRobin: roundRobin}
+	pool.add(urls)
+	return &pool
+}
+
+func (p *endpointPool) add(urls []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, url := range urls {
+		known := slices.ContainsFunc(p.endpoints, func(e *endpoint) bool {
+			return e.url == url
+		})
+		if !known {
+			p.endpoints = append(p.endpoints, &endpoint{url: url})
+		}
+	}
+}
+
+func (p *endpointPool) size() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.endpoints)
+}
+
+// next returns the endpoint for the next request. If all endpoints are dead,
+// the endpoint whose timeout expires first is returned.
+func (p *endpointPool) next() *endpoint {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+
+	start := p.current
+	if p.roundRobin {
+		start++
+	}
+
+	for offset := 0; offset < len(p.endpoints); offset++ {
+		index := (start + offset) % len(p.endpoints)
+		if !p.endpoints[index].deadUntil.After(now) {
+			p.current = index
+			return p.endpoints[index]
+		}
+	}
+
+	index := 0
+	for candidate := range p.endpoints {
+		if p.endpoints[candidate].deadUntil.Before(p.endpoints[index].deadUntil) {
+			index = candidate
+		}
+	}
+	p.current = index
+
+	return p.endpoints[index]
+}
+
+func (p *endpointPool) markDead(e *endpoint) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	timeout := deadEndpointTimeout << min(e.failures, 10)
+	if timeout > deadEndpointMaxTimeout {
+		timeout = deadEndpointMaxTimeout
+	}
+
+	e.failures++
+	e.deadUntil = time.Now().Add(timeout)
+}
+
+func (p *endpointPool) markAlive(e *endpoint) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	e.failures = 0
+	e.deadUntil = time.Time{}
+}
+
+// currentURL returns the URL of the endpoint which served the latest request.
+func (p *endpointPool) currentURL() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.endpoints[p.current].url
+}
@@ -0,0 +1,116 @@
+package elasticsearch
+
+import (
+	"time"
+)
+
+// OperationStats are the operation counters of node and index stats. They
+// count the operations since the node started.
+type OperationStats struct {
+	Indexing struct {
+		IndexTotal int64 `json:"index_total"`
+	} `json:"indexing"`
+	Search struct {
+		QueryTotal int64 `json:"query_total"`
+	} `json:"search"`
+	Get struct {
+		Total int64 `json:"total"`
+	} `json:"get"`
+	Refresh struct {
+		Total int64 `json:"total"`
+	} `json:"refresh"`
+	Merges struct {
+		Total int64 `json:"total"`
+	} `json:"merges"`
+}
+
+// OperationRates are the operations per second between two snapshots of
+// OperationStats.
+type OperationRates struct {
+	Indexing float64
+	Search   float64
+	Get      float64
+	Refresh  float64
+	Merge    float64
+}
+
+// Rates returns the operations per second since the previous stats were
+// taken. Counters which decreased (e.g. after a node restart) have no rate.
+func (s OperationStats) Rates(previous OperationStats, elapsed time.Duration) OperationRates {
+	if elapsed <= 0 {
+		return OperationRates{}
+	}
+
+	rate := func(current int64, previous int64) float64 {
+		if current < previous {
+			return 0
+		}
+		return float64(current-previous) / elapsed.Seconds()
+	}
+
+	return OperationRates{
+		Indexing: rate(s.Indexing.IndexTotal, previous.Indexing.IndexTotal),
+		Search:   rate(s.Search.QueryTotal, previous.Search.QueryTotal),
+		Get:      rate(s.Get.Total, previous.Get.Total),
+		Refresh:  rate(s.Refresh.Total, previous.Refresh.Total),
+		Merge:    rate(s.Merges.Total, previous.Merges.Total),
+	}
+}
+
+// Add returns the sum of both rates.
+func (r OperationRates) Add(other OperationRates) OperationRates {
+	return OperationRates{
+		Indexing: r.Indexing + other.Indexing,
+		Search:   r.Search + other.Search,
+		Get:      r.Get + other.Get,
+		Refresh:  r.Refresh + other.Refresh,
+		Merge:    r.Merge + other.Merge,
+	}
+}
+
+// nodeRates returns the rates of the nodes contained in both snapshots by node
+// ID. The elapsed time is taken from the timestamps of the node stats.
+func nodeRates(previous []NodeStats, current []NodeStats) map[string]OperationRates {
+	previousById := make(map[string]NodeStats, len(previous))
+	for _, nodeStats := range previous {
+		previousById[nodeStats.Id] = nodeStats
+	}
+
+	rates := make(map[string]OperationRates, len(current))
+	for _, nodeStats := range current {
+		if previousNodeStats, ok := previousById[nodeStats.Id]; ok {
+			elapsed := time.Duration(nodeStats.Timestamp-previousNodeStats.Timestamp) * time.Millisecond
+			rates[nodeStats.Id] = nodeStats.Indices.Rates(previousNodeStats.Indices.OperationStats, elapsed)
+		}
+	}
+
+	return rates
+}
+
+// indexRates returns the rates of the indices contained in both snapshots by
+// index name. Index stats have no timestamp, the elapsed time between the
+// fetches is used instead.
+func indexRates(previous []IndexStats, current []IndexStats, elapsed time.Duration) map[string]OperationRates {
+	previousByName := make(map[string]IndexStats, len(previous))
+	for _, indexStats := range previous {
+		previousByName[indexStats.Name] = indexStats
+	}
+
+	rates := make(map[string]OperationRates, len(current))
+	for _, indexStats := range current {
+		if previousIndexStats, ok := previousByName[indexStats.Name]; ok {
+			rates[indexStats.Name] = indexStats.Total.Rates(previousIndexStats.Total.OperationStats, elapsed)
+		}
+	}
+
+	return rates
+}
+
+// ClusterRates returns the sum of the rates of all nodes.
+func (d *ClusterData) ClusterRates() OperationRates {
+	var rates OperationRates
+	for _, nodeRates := range d.NodeRates {
+		rates = rates.Add(nodeRates)
+	}
+	return rates
+}
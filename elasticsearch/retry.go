@@ -0,0 +1,72 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"esmon/config"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// retryPolicy retries requests which failed due to a transient error. The delay
+// between attempts grows exponentially and is randomized (full jitter), so the
+// clients of an overloaded cluster do not retry in lockstep.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	statusCodes []int
+}
+
+func newRetryPolicy(retryConfig config.RetryConfig) retryPolicy {
+	return retryPolicy{
+		maxAttempts: max(int(retryConfig.MaxAttempts), 1),
+		baseDelay:   time.Duration(retryConfig.BaseDelay) * time.Millisecond,
+		maxDelay:    time.Duration(retryConfig.MaxDelay) * time.Millisecond,
+		statusCodes: retryConfig.StatusCodes,
+	}
+}
+
+// retryable reports whether a request which failed with err may succeed when
+// it is sent again. Responses are retryable if their status code is listed,
+// errors without a response (e.g. timeouts or refused connections) always are.
+func (p retryPolicy) retryable(err error) bool {
+	if err == nil || isDecodeError(err) {
+		return false
+	}
+
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return slices.Contains(p.statusCodes, apiError.StatusCode)
+	}
+
+	return !errors.Is(err, context.Canceled)
+}
+
+// delay returns the randomized delay before the given retry, starting at 1.
+func (p retryPolicy) delay(retry int) time.Duration {
+	ceiling := p.baseDelay << min(retry-1, 30)
+	if ceiling > p.maxDelay || ceiling <= 0 {
+		ceiling = p.maxDelay
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// wait blocks for the delay or until the context is done.
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
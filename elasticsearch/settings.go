@@ -0,0 +1,89 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// The settings are read from every level, settings which are not set
+// explicitly are included as defaults.
+var clusterSettingsPath = "/_cluster/settings?include_defaults&filter_path=" + filterPath("*", clusterSettings{})
+
+// clusterSettings are the settings of a level (persistent, transient or
+// defaults) of the _cluster/settings response.
+type clusterSettings struct {
+	Cluster struct {
+		Routing struct {
+			Allocation struct {
+				Disk struct {
+					ThresholdEnabled string `json:"threshold_enabled"`
+					// the values are strings, the settings of newer
+					// versions nested below a watermark (e.g.
+					// low.max_headroom) may turn it into an object
+					Watermark map[string]json.RawMessage `json:"watermark"`
+				} `json:"disk"`
+			} `json:"allocation"`
+		} `json:"routing"`
+	} `json:"cluster"`
+	Indices struct {
+		Recovery struct {
+			MaxBytesPerSec string `json:"max_bytes_per_sec"`
+		} `json:"recovery"`
+	} `json:"indices"`
+}
+
+// settingsLevels are the levels of the cluster settings from the lowest to
+// the highest precedence.
+var settingsLevels = []string{"defaults", "persistent", "transient"}
+
+// ClusterSettings are the effective values of the cluster settings displayed
+// by esmon.
+type ClusterSettings struct {
+	// DiskWatermarks are nil if the disk threshold decider is disabled
+	// (cluster.routing.allocation.disk.threshold_enabled)
+	DiskWatermarks *DiskWatermarks
+	// RecoveryMaxBytesPerSec limits the recovery bandwidth of each node
+	// (indices.recovery.max_bytes_per_sec)
+	RecoveryMaxBytesPerSec string
+}
+
+func (c *Client) fetchClusterSettings(ctx context.Context) (*ClusterSettings, error) {
+	body, err := c.get(ctx, clusterSettingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var levels map[string]clusterSettings
+	if err = json.Unmarshal(body, &levels); err != nil {
+		return nil, err
+	}
+
+	var settings ClusterSettings
+	thresholdEnabled := true
+	watermarkValues := make(map[string]string)
+	for _, level := range settingsLevels {
+		if value := levels[level].Cluster.Routing.Allocation.Disk.ThresholdEnabled; value != "" {
+			if thresholdEnabled, err = strconv.ParseBool(value); err != nil {
+				return nil, err
+			}
+		}
+
+		for name, raw := range levels[level].Cluster.Routing.Allocation.Disk.Watermark {
+			var value string
+			if err := json.Unmarshal(raw, &value); err == nil {
+				watermarkValues[name] = value
+			}
+		}
+
+		if maxBytesPerSec := levels[level].Indices.Recovery.MaxBytesPerSec; maxBytesPerSec != "" {
+			settings.RecoveryMaxBytesPerSec = maxBytesPerSec
+		}
+	}
+
+	if settings.DiskWatermarks, err = diskWatermarks(thresholdEnabled, watermarkValues); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
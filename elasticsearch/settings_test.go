@@ -0,0 +1,62 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFetchClusterSettingsDiskThreshold(t *testing.T) {
+	const defaults = `"defaults":{"cluster":{"routing":{"allocation":{"disk":{"threshold_enabled":"true",` +
+		`"watermark":{"low":"85%","high":"90%","flood_stage":"95%","low.max_headroom":"200gb"}}}}}}`
+
+	tests := []struct {
+		name           string
+		body           string
+		wantWatermarks bool
+	}{
+		{
+			name:           "enabled by default",
+			body:           `{` + defaults + `}`,
+			wantWatermarks: true,
+		},
+		{
+			name:           "disabled persistently",
+			body:           `{"persistent":{"cluster":{"routing":{"allocation":{"disk":{"threshold_enabled":"false"}}}}},` + defaults + `}`,
+			wantWatermarks: false,
+		},
+		{
+			name: "enabled transiently",
+			body: `{"persistent":{"cluster":{"routing":{"allocation":{"disk":{"threshold_enabled":"false"}}}}},` +
+				`"transient":{"cluster":{"routing":{"allocation":{"disk":{"threshold_enabled":"true"}}}}},` + defaults + `}`,
+			wantWatermarks: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if !strings.Contains(r.URL.Query().Get("filter_path"), "disk.threshold_enabled") {
+					t.Errorf("expected the threshold setting to be requested, got %s", r.URL)
+				}
+				w.Write([]byte(test.body))
+			})
+
+			settings, err := client.fetchClusterSettings(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !test.wantWatermarks {
+				if settings.DiskWatermarks != nil {
+					t.Errorf("expected no watermarks, got %+v", *settings.DiskWatermarks)
+				}
+				return
+			}
+			if settings.DiskWatermarks == nil || settings.DiskWatermarks.High.UsedPercent != 90 {
+				t.Errorf("expected the watermarks of the defaults, got %+v", settings.DiskWatermarks)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"sync"
+)
+
+const (
+	SnapshotStateInProgress = "IN_PROGRESS"
+	SnapshotStateSuccess    = "SUCCESS"
+	SnapshotStateFailed     = "FAILED"
+	SnapshotStatePartial    = "PARTIAL"
+
+	// latestSnapshotCount is the number of snapshots listed per repository
+	latestSnapshotCount = 5
+)
+
+var (
+	snapshotRepositoriesPath = "/_snapshot?filter_path=" + filterPath("*", SnapshotRepository{})
+	snapshotStatusPath       = "/_snapshot/_status?filter_path=" + filterPath("snapshots", SnapshotStatus{})
+	snapshotPoliciesPath     = "/_slm/policy?human&filter_path=" + filterPath("*", SnapshotPolicy{})
+
+	snapshotsFilterPath = filterPath("snapshots", Snapshot{})
+)
+
+// snapshotsPath returns the path listing the latest count snapshots of the
+// repository. Clusters which can not sort the snapshots list all of them, the
+// latest are picked while decoding.
+func snapshotsPath(version Version, repository string, count int) string {
+	path := "/_snapshot/" + url.PathEscape(repository) + "/_all?filter_path=" + snapshotsFilterPath
+	if version.Supports(SnapshotSortCapability) {
+		path += fmt.Sprintf("&sort=start_time&order=desc&size=%d&index_details", count)
+	}
+	return path
+}
+
+// SnapshotRepository is a registered snapshot repository with its latest
+// snapshots, newest first.
+type SnapshotRepository struct {
+	Name      string // manually added while fetching
+	Type      string `json:"type"`
+	Snapshots []Snapshot
+}
+
+type Snapshot struct {
+	Snapshot          string `json:"snapshot"`
+	State             string `json:"state"`
+	StartTimeInMillis int64  `json:"start_time_in_millis"`
+	EndTimeInMillis   int64  `json:"end_time_in_millis"`
+	Failures          []struct {
+		Index   string `json:"index"`
+		ShardId int    `json:"shard_id"`
+		Reason  string `json:"reason"`
+		NodeId  string `json:"node_id"`
+	} `json:"failures"`
+	Shards struct {
+		Total      int `json:"total"`
+		Failed     int `json:"failed"`
+		Successful int `json:"successful"`
+	} `json:"shards"`
+	// only reported by clusters supporting SnapshotSortCapability
+	IndexDetails map[string]struct {
+		SizeInBytes int64 `json:"size_in_bytes"`
+	} `json:"index_details"`
+}
+
+// SizeInBytes returns the size of the indices in the snapshot, including data
+// shared with other snapshots. It is zero if the cluster does not report it.
+func (s Snapshot) SizeInBytes() int64 {
+	var size int64
+	for _, index := range s.IndexDetails {
+		size += index.SizeInBytes
+	}
+	return size
+}
+
+// Failed reports whether the snapshot did not store every shard.
+func (s Snapshot) Failed() bool {
+	return s.State == SnapshotStateFailed || s.State == SnapshotStatePartial
+}
+
+// SnapshotStats are the file sizes of a running snapshot or of one of its
+// shards.
+type SnapshotStats struct {
+	Processed struct {
+		SizeInBytes int64 `json:"size_in_bytes"`
+	} `json:"processed"`
+	Total struct {
+		SizeInBytes int64 `json:"size_in_bytes"`
+	} `json:"total"`
+	StartTimeInMillis int64 `json:"start_time_in_millis"`
+	TimeInMillis      int64 `json:"time_in_millis"`
+}
+
+// Percent returns the processed share of the total size.
+func (s SnapshotStats) Percent() float64 {
+	if s.Total.SizeInBytes == 0 {
+		return 0
+	}
+	return float64(s.Processed.SizeInBytes) / float64(s.Total.SizeInBytes) * 100
+}
+
+// SnapshotStatus is the progress of a running snapshot as reported by
+// _snapshot/_status.
+type SnapshotStatus struct {
+	Snapshot    string `json:"snapshot"`
+	Repository  string `json:"repository"`
+	State       string `json:"state"`
+	ShardsStats struct {
+		Initializing int `json:"initializing"`
+		Started      int `json:"started"`
+		Finalizing   int `json:"finalizing"`
+		Done         int `json:"done"`
+		Failed       int `json:"failed"`
+		Total        int `json:"total"`
+	} `json:"shards_stats"`
+	Stats   SnapshotStats `json:"stats"`
+	Indices map[string]struct {
+		Shards map[string]struct {
+			Stage string        `json:"stage"`
+			Stats SnapshotStats `json:"stats"`
+		} `json:"shards"`
+	} `json:"indices"`
+}
+
+// Snapshots are the snapshot repositories sorted by name and the snapshots in
+// progress.
+type Snapshots struct {
+	Repositories []SnapshotRepository
+	InProgress   []SnapshotStatus
+}
+
+// SnapshotPolicy is a snapshot lifecycle management policy. The invocation
+// times are epoch milliseconds, zero if the policy has not run yet.
+type SnapshotPolicy struct {
+	Name   string // manually added while fetching
+	Policy struct {
+		Schedule   string `json:"schedule"`
+		Repository string `json:"repository"`
+	} `json:"policy"`
+	LastSuccess   SnapshotInvocation `json:"last_success"`
+	LastFailure   SnapshotInvocation `json:"last_failure"`
+	NextExecution string             `json:"next_execution"`
+}
+
+type SnapshotInvocation struct {
+	SnapshotName string `json:"snapshot_name"`
+	Time         int64  `json:"time"`
+	Details      string `json:"details"`
+}
+
+// Failed reports whether the last run of the policy failed.
+func (p SnapshotPolicy) Failed() bool {
+	return p.LastFailure.Time > p.LastSuccess.Time
+}
+
+// FailedSnapshots returns the names of the failed snapshots among the latest
+// snapshot of each repository and the last run of each snapshot lifecycle
+// policy. Sources which were never fetched do not contribute, a snapshot
+// reported by several is only returned once.
+func (d *ClusterData) FailedSnapshots() []string {
+	var names []string
+	for _, policy := range d.SnapshotPolicies {
+		if policy.Failed() {
+			names = append(names, policy.LastFailure.SnapshotName)
+		}
+	}
+
+	for _, snapshot := range d.LatestSnapshots {
+		if snapshot.Failed() && !slices.Contains(names, snapshot.Snapshot) {
+			names = append(names, snapshot.Snapshot)
+		}
+	}
+
+	for _, repository := range d.Snapshots.Repositories {
+		for _, snapshot := range repository.Snapshots {
+			if snapshot.State == SnapshotStateInProgress {
+				continue
+			}
+			if snapshot.Failed() && !slices.Contains(names, snapshot.Snapshot) {
+				names = append(names, snapshot.Snapshot)
+			}
+			break
+		}
+	}
+
+	return names
+}
+
+// fetchSnapshotRepositories returns the registered snapshot repositories,
+// sorted by name, with their latest count snapshots. The snapshots of the
+// repositories are fetched concurrently.
+func (c *Client) fetchSnapshotRepositories(ctx context.Context, version Version, count int) ([]SnapshotRepository, error) {
+	body, err := c.get(ctx, snapshotRepositoriesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var repositoryMap map[string]SnapshotRepository
+	if err = json.Unmarshal(body, &repositoryMap); err != nil {
+		return nil, err
+	}
+
+	var repositories []SnapshotRepository
+	for name, repository := range repositoryMap {
+		repository.Name = name
+		repositories = append(repositories, repository)
+	}
+
+	var waitGroup sync.WaitGroup
+	errs := make([]error, len(repositories))
+	for index := range repositories {
+		waitGroup.Add(1)
+		go func(index int) {
+			defer waitGroup.Done()
+			repository := &repositories[index]
+			repository.Snapshots, errs[index] = c.fetchLatestSnapshots(ctx, version, repository.Name, count)
+		}(index)
+	}
+	waitGroup.Wait()
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].Name < repositories[j].Name
+	})
+
+	return repositories, nil
+}
+
+func (c *Client) fetchSnapshots(ctx context.Context, version Version) (*Snapshots, error) {
+	var (
+		snapshots Snapshots
+		err       error
+	)
+
+	if snapshots.Repositories, err = c.fetchSnapshotRepositories(ctx, version, latestSnapshotCount); err != nil {
+		return nil, err
+	}
+
+	body, err := c.get(ctx, snapshotStatusPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var status struct {
+		Snapshots []SnapshotStatus `json:"snapshots"`
+	}
+	if err = json.Unmarshal(body, &status); err != nil {
+		return nil, err
+	}
+	snapshots.InProgress = status.Snapshots
+
+	return &snapshots, nil
+}
+
+// fetchLatestSnapshot returns the newest snapshot of each repository, which
+// may still be in progress. Repositories without snapshots are left out.
+// Clusters which can not sort the snapshots would have to list every snapshot
+// of every repository, so they have none.
+func (c *Client) fetchLatestSnapshot(ctx context.Context, version Version) (*[]Snapshot, error) {
+	var snapshots []Snapshot
+
+	if !version.Supports(SnapshotSortCapability) {
+		return &snapshots, nil
+	}
+
+	repositories, err := c.fetchSnapshotRepositories(ctx, version, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repository := range repositories {
+		snapshots = append(snapshots, repository.Snapshots...)
+	}
+
+	return &snapshots, nil
+}
+
+// fetchLatestSnapshots returns the latest count snapshots of the repository,
+// newest first. A repository can hold thousands of snapshots, so they are
+// decoded one at a time.
+func (c *Client) fetchLatestSnapshots(ctx context.Context, version Version, repository string, count int) ([]Snapshot, error) {
+	var snapshots []Snapshot
+
+	err := c.decode(ctx, snapshotsPath(version, repository, count), func(decoder *json.Decoder) error {
+		snapshots = nil
+
+		return decodeObject(decoder, func(key string) error {
+			if key != "snapshots" {
+				return skipValue(decoder)
+			}
+
+			return decodeArray(decoder, func() error {
+				var snapshot Snapshot
+				if err := decoder.Decode(&snapshot); err != nil {
+					return err
+				}
+
+				index := sort.Search(len(snapshots), func(i int) bool {
+					return snapshots[i].StartTimeInMillis < snapshot.StartTimeInMillis
+				})
+				if index < count {
+					snapshots = append(snapshots[:index], append([]Snapshot{snapshot}, snapshots[index:]...)...)
+					if len(snapshots) > count {
+						snapshots = snapshots[:count]
+					}
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// fetchSnapshotPolicies returns the snapshot lifecycle policies. Clusters
+// without the _slm endpoints (e.g. the OSS distribution) have no policies.
+func (c *Client) fetchSnapshotPolicies(ctx context.Context) (*[]SnapshotPolicy, error) {
+	var policies []SnapshotPolicy
+
+	body, err := c.get(ctx, snapshotPoliciesPath)
+	if err != nil {
+		if isUnsupportedEndpoint(err) {
+			return &policies, nil
+		}
+		return nil, err
+	}
+
+	var policyMap map[string]SnapshotPolicy
+	if err = json.Unmarshal(body, &policyMap); err != nil {
+		return nil, err
+	}
+
+	for name, policy := range policyMap {
+		policy.Name = name
+		policies = append(policies, policy)
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+
+	return &policies, nil
+}
@@ -0,0 +1,123 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// oldVersion can not sort snapshots.
+var oldVersion = Version{Distribution: DistributionElasticsearch, Number: "7.10.2", Major: 7, Minor: 10}
+
+// snapshotHandler serves three repositories, one of them without snapshots.
+// The snapshots are not sorted and the requested size is checked.
+func snapshotHandler(t *testing.T, wantSize string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_snapshot":
+			w.Write([]byte(`{"backups":{"type":"fs"},"empty":{"type":"fs"},"failing":{"type":"s3"}}`))
+		case "/_snapshot/backups/_all", "/_snapshot/empty/_all", "/_snapshot/failing/_all":
+			if size := r.URL.Query().Get("size"); size != wantSize {
+				t.Errorf("expected size %q, got %q", wantSize, size)
+			}
+			w.Write([]byte(map[string]string{
+				"/_snapshot/backups/_all": `{"snapshots":[` +
+					`{"snapshot":"backup-1","state":"FAILED","start_time_in_millis":1000},` +
+					`{"snapshot":"backup-3","state":"SUCCESS","start_time_in_millis":3000},` +
+					`{"snapshot":"backup-2","state":"PARTIAL","start_time_in_millis":2000}]}`,
+				"/_snapshot/empty/_all":   `{"snapshots":[]}`,
+				"/_snapshot/failing/_all": `{"snapshots":[{"snapshot":"failing-1","state":"PARTIAL","start_time_in_millis":1000}]}`,
+			}[r.URL.Path]))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func TestFetchLatestSnapshot(t *testing.T) {
+	client := newTestClient(t, snapshotHandler(t, "1"))
+
+	snapshots, err := client.fetchLatestSnapshot(context.Background(), testVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, snapshot := range *snapshots {
+		names = append(names, snapshot.Snapshot)
+	}
+	// the fake cluster does not sort, the latest snapshot is picked while
+	// decoding
+	if want := []string{"backup-3", "failing-1"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestFetchLatestSnapshotWithoutSort(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	snapshots, err := client.fetchLatestSnapshot(context.Background(), oldVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*snapshots) > 0 {
+		t.Errorf("expected no snapshots, got %v", *snapshots)
+	}
+}
+
+func TestFetchSnapshotRepositoriesWithoutSort(t *testing.T) {
+	client := newTestClient(t, snapshotHandler(t, ""))
+
+	repositories, err := client.fetchSnapshotRepositories(context.Background(), oldVersion, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][]string)
+	var names []string
+	for _, repository := range repositories {
+		names = append(names, repository.Name)
+		for _, snapshot := range repository.Snapshots {
+			got[repository.Name] = append(got[repository.Name], snapshot.Snapshot)
+		}
+	}
+
+	if want := []string{"backups", "empty", "failing"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected the repositories sorted by name, got %v", names)
+	}
+	want := map[string][]string{"backups": {"backup-3", "backup-2"}, "failing": {"failing-1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the latest snapshots newest first, got %v", got)
+	}
+}
+
+func TestFailedSnapshots(t *testing.T) {
+	clusterData := ClusterData{
+		SnapshotPolicies: []SnapshotPolicy{
+			{Name: "nightly", LastFailure: SnapshotInvocation{SnapshotName: "nightly-2", Time: 2}, LastSuccess: SnapshotInvocation{Time: 1}},
+			{Name: "hourly", LastFailure: SnapshotInvocation{SnapshotName: "hourly-1", Time: 1}, LastSuccess: SnapshotInvocation{Time: 2}},
+		},
+		LatestSnapshots: []Snapshot{
+			{Snapshot: "nightly-2", State: SnapshotStatePartial},
+			{Snapshot: "manual-1", State: SnapshotStateFailed},
+			{Snapshot: "manual-2", State: SnapshotStateInProgress},
+			{Snapshot: "weekly-1", State: SnapshotStateSuccess},
+		},
+		Snapshots: Snapshots{Repositories: []SnapshotRepository{
+			{Name: "backups", Snapshots: []Snapshot{
+				{Snapshot: "manual-3", State: SnapshotStateInProgress},
+				{Snapshot: "manual-1", State: SnapshotStateFailed},
+			}},
+		}},
+	}
+
+	want := []string{"nightly-2", "manual-1"}
+	if got := clusterData.FailedSnapshots(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package elasticsearch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"esmon/config"
+	"fmt"
+	"os"
+)
+
+// TLSConfig builds the TLS configuration for the cluster. A configured CA file
+// replaces the system certificate pool for server certificate verification and a
+// configured client certificate is presented for mutual TLS.
+func TLSConfig(clusterConfig *config.ClusterConfig, insecure bool) (*tls.Config, error) {
+	tlsConfig := tls.Config{
+		InsecureSkipVerify: insecure,
+		ServerName:         clusterConfig.ServerName,
+	}
+
+	if clusterConfig.CaFile != "" {
+		caCertificates, err := os.ReadFile(clusterConfig.CaFile)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to read CA file: %s", err))
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCertificates) {
+			return nil, errors.New(fmt.Sprintf("No PEM encoded certificate found in CA file %s", clusterConfig.CaFile))
+		}
+
+		tlsConfig.RootCAs = certPool
+	}
+
+	if clusterConfig.ClientCertFile != "" || clusterConfig.ClientKeyFile != "" {
+		clientCertificate, err := tls.LoadX509KeyPair(clusterConfig.ClientCertFile, clusterConfig.ClientKeyFile)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to load client certificate: %s", err))
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{clientCertificate}
+	}
+
+	return &tlsConfig, nil
+}
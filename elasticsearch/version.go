@@ -0,0 +1,173 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DistributionElasticsearch = "Elasticsearch"
+	DistributionOpenSearch    = "OpenSearch"
+)
+
+// Capability denotes an API feature which is not provided by every supported
+// distribution and version.
+type Capability string
+
+const (
+	// indices.shard_stats in node and index stats
+	ShardStatsCapability Capability = "shard_stats"
+	// cluster_manager node selector replacing the master node selector
+	ClusterManagerCapability Capability = "cluster_manager"
+	// sort, size and index_details parameters of the get snapshots API
+	SnapshotSortCapability Capability = "snapshot_sort"
+	// snapshot lifecycle management (_slm) policies
+	SnapshotLifecycleCapability Capability = "snapshot_lifecycle"
+	// index lifecycle management (_ilm)
+	IndexLifecycleCapability Capability = "index_lifecycle"
+	// index state management (_plugins/_ism)
+	IndexStateManagementCapability Capability = "index_state_management"
+	// hidden value of the expand_wildcards parameter
+	HiddenIndicesCapability Capability = "hidden_indices"
+	// data streams (_data_stream)
+	DataStreamCapability Capability = "data_stream"
+)
+
+type minimumVersion struct {
+	major int
+	minor int
+}
+
+// capabilityTable contains the minimum version per distribution providing a
+// capability. A distribution which is not listed does not provide it.
+var capabilityTable = map[Capability]map[string]minimumVersion{
+	ShardStatsCapability: {
+		DistributionElasticsearch: {7, 15},
+	},
+	ClusterManagerCapability: {
+		DistributionOpenSearch: {2, 0},
+	},
+	SnapshotSortCapability: {
+		DistributionElasticsearch: {7, 14},
+	},
+	SnapshotLifecycleCapability: {
+		DistributionElasticsearch: {7, 4},
+	},
+	IndexLifecycleCapability: {
+		DistributionElasticsearch: {6, 6},
+	},
+	IndexStateManagementCapability: {
+		DistributionOpenSearch: {1, 0},
+	},
+	HiddenIndicesCapability: {
+		DistributionElasticsearch: {7, 7},
+		DistributionOpenSearch:    {1, 0},
+	},
+	DataStreamCapability: {
+		DistributionElasticsearch: {7, 9},
+		DistributionOpenSearch:    {1, 0},
+	},
+}
+
+type Version struct {
+	Distribution string
+	Number       string
+	Major        int
+	Minor        int
+	Patch        int
+}
+
+type rootInfo struct {
+	Version struct {
+		Number       string `json:"number"`
+		Distribution string `json:"distribution"`
+	} `json:"version"`
+}
+
+func (v Version) String() string {
+	if v.Number == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", v.Distribution, v.Number)
+}
+
+// AtLeast reports whether the version is equal to or newer than major.minor.
+func (v Version) AtLeast(major int, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+// Supports reports whether the distribution and version provide the capability.
+// An unknown version does not support any capability.
+func (v Version) Supports(capability Capability) bool {
+	minimum, ok := capabilityTable[capability][v.Distribution]
+	if !ok {
+		return false
+	}
+	return v.AtLeast(minimum.major, minimum.minor)
+}
+
+func parseVersion(info *rootInfo) Version {
+	version := Version{
+		Distribution: DistributionElasticsearch,
+		Number:       info.Version.Number,
+	}
+
+	if info.Version.Distribution == "opensearch" {
+		version.Distribution = DistributionOpenSearch
+	}
+
+	// pre-release suffixes like 8.0.0-SNAPSHOT are ignored
+	number, _, _ := strings.Cut(info.Version.Number, "-")
+	parts := strings.Split(number, ".")
+
+	if len(parts) > 0 {
+		version.Major, _ = strconv.Atoi(parts[0])
+	}
+	if len(parts) > 1 {
+		version.Minor, _ = strconv.Atoi(parts[1])
+	}
+	if len(parts) > 2 {
+		version.Patch, _ = strconv.Atoi(parts[2])
+	}
+
+	return version
+}
+
+// Version returns the distribution and version of the cluster. It is detected
+// once per client, failed detections are retried on the next call.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	c.versionMutex.Lock()
+	defer c.versionMutex.Unlock()
+
+	if c.version != nil {
+		return c.version, nil
+	}
+
+	version, err := c.fetchVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.version = version
+
+	return c.version, nil
+}
+
+func (c *Client) fetchVersion(ctx context.Context) (*Version, error) {
+	body, err := c.get(ctx, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var info rootInfo
+	if err = json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+
+	version := parseVersion(&info)
+
+	return &version, nil
+}
@@ -0,0 +1,157 @@
+package elasticsearch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DiskWatermarkLevel is the highest disk watermark exceeded by a node.
+type DiskWatermarkLevel int
+
+const (
+	NoDiskWatermark DiskWatermarkLevel = iota
+	LowDiskWatermark
+	HighDiskWatermark
+	FloodStageDiskWatermark
+)
+
+func (l DiskWatermarkLevel) String() string {
+	switch l {
+	case LowDiskWatermark:
+		return "low"
+	case HighDiskWatermark:
+		return "high"
+	case FloodStageDiskWatermark:
+		return "flood"
+	}
+	return ""
+}
+
+// DiskWatermark is a threshold of the disk usage of a node. It is either a
+// percentage of used disk space or an amount of free disk space.
+type DiskWatermark struct {
+	UsedPercent float64
+	FreeBytes   int64
+}
+
+// Exceeded reports whether the disk usage crosses the watermark. Like
+// Elasticsearch, the space available to the node counts as free.
+func (w DiskWatermark) Exceeded(totalBytes int64, availableBytes int64) bool {
+	if totalBytes == 0 {
+		return false
+	}
+	if w.FreeBytes > 0 {
+		return availableBytes < w.FreeBytes
+	}
+	return UsedDiskPercent(totalBytes, availableBytes) > w.UsedPercent
+}
+
+// DiskWatermarks are the effective cluster.routing.allocation.disk.watermark
+// settings.
+type DiskWatermarks struct {
+	Low        DiskWatermark
+	High       DiskWatermark
+	FloodStage DiskWatermark
+}
+
+// Level returns the highest watermark the disk usage of the node crosses.
+func (w DiskWatermarks) Level(node NodeStats) DiskWatermarkLevel {
+	total, available := node.Fs.Total.TotalInBytes, node.Fs.Total.AvailableInBytes
+
+	switch {
+	case w.FloodStage.Exceeded(total, available):
+		return FloodStageDiskWatermark
+	case w.High.Exceeded(total, available):
+		return HighDiskWatermark
+	case w.Low.Exceeded(total, available):
+		return LowDiskWatermark
+	}
+	return NoDiskWatermark
+}
+
+// UsedDiskPercent returns the percentage of the disk space which is not
+// available to the node.
+func UsedDiskPercent(totalBytes int64, availableBytes int64) float64 {
+	if totalBytes == 0 {
+		return 0
+	}
+	return float64(totalBytes-availableBytes) / float64(totalBytes) * 100
+}
+
+// diskWatermarks parses the low, high and flood stage watermark settings. The
+// watermarks are ignored, and nil is returned, if the disk threshold decider
+// is disabled.
+func diskWatermarks(thresholdEnabled bool, values map[string]string) (*DiskWatermarks, error) {
+	if !thresholdEnabled {
+		return nil, nil
+	}
+
+	var watermarks DiskWatermarks
+	for name, watermark := range map[string]*DiskWatermark{
+		"low":         &watermarks.Low,
+		"high":        &watermarks.High,
+		"flood_stage": &watermarks.FloodStage,
+	} {
+		value, ok := values[name]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Disk watermark %s is missing in the cluster settings", name))
+		}
+
+		var err error
+		if *watermark, err = parseDiskWatermark(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return &watermarks, nil
+}
+
+// byteUnits are the units of byte size settings.
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"kb", 1 << 10},
+	{"mb", 1 << 20},
+	{"gb", 1 << 30},
+	{"tb", 1 << 40},
+	{"pb", 1 << 50},
+	{"k", 1 << 10},
+	{"m", 1 << 20},
+	{"g", 1 << 30},
+	{"t", 1 << 40},
+	{"p", 1 << 50},
+	{"b", 1},
+}
+
+// parseDiskWatermark parses a watermark setting which is a percentage (85%),
+// a ratio (0.85) or a byte size of free disk space (500mb).
+func parseDiskWatermark(value string) (DiskWatermark, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		usedPercent, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return DiskWatermark{}, errors.New(fmt.Sprintf("Unable to parse disk watermark %s", value))
+		}
+		return DiskWatermark{UsedPercent: usedPercent}, nil
+	}
+
+	if ratio, err := strconv.ParseFloat(value, 64); err == nil {
+		return DiskWatermark{UsedPercent: ratio * 100}, nil
+	}
+
+	for _, unit := range byteUnits {
+		if size, ok := strings.CutSuffix(value, unit.suffix); ok {
+			bytes, err := strconv.ParseFloat(strings.TrimSpace(size), 64)
+			if err != nil {
+				break
+			}
+			return DiskWatermark{FreeBytes: int64(bytes * float64(unit.multiplier))}, nil
+		}
+	}
+
+	return DiskWatermark{}, errors.New(fmt.Sprintf("Unable to parse disk watermark %s", value))
+}
@@ -2,15 +2,22 @@ package main
 
 import (
 	"esmon/tui"
+	"io"
 	"log"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	// the terminal belongs to the TUI, log messages are only written to the
+	// debug log file if one is given
+	log.SetOutput(io.Discard)
+
 	p := tea.NewProgram(tui.NewMainModel(), tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
+		log.SetOutput(os.Stderr)
 		log.Fatal("Failed to start program: ", err)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"esmon/config"
 	"esmon/constants"
 	"esmon/tui/styles"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -15,7 +16,8 @@ import (
 var (
 	clusterTableColumns []table.Column = []table.Column{
 		{Title: "↑Alias", Width: 20},
-		{Title: "Endpoint", Width: 20},
+		{Title: "Endpoints", Width: 20},
+		{Title: "Authentication", Width: 20},
 		{Title: "Username", Width: 20},
 		{Title: "Password", Width: 20},
 	}
@@ -107,7 +109,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, defaultKeyMap.enter):
-			clusterAlias := m.clusterTable.SelectedRow()[1]
+			clusterAlias := m.clusterTable.SelectedRow()[0]
 			cmds = append(cmds, selectCluster(clusterAlias))
 		}
 
@@ -118,8 +120,20 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				password = constants.RedactedPassword
 			}
 
+			endpoints := strings.Join(row.AllEndpoints(), ", ")
+
+			authentication := ""
+			switch {
+			case row.ApiKey != "":
+				authentication = "API key"
+			case row.BearerToken != "":
+				authentication = "Bearer token"
+			case row.Username != "":
+				authentication = "Basic"
+			}
+
 			clusterTableRows = append(clusterTableRows, table.Row{
-				row.Alias, row.Endpoint, row.Username, password,
+				row.Alias, endpoints, authentication, row.Username, password,
 			})
 		}
 
@@ -149,9 +163,9 @@ func setStyles(theme *styles.Theme) {
 		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
 }
 
-func selectCluster(endpoint string) tea.Cmd {
+func selectCluster(alias string) tea.Cmd {
 	return func() tea.Msg {
-		return ClusterChangeMsg(endpoint)
+		return ClusterChangeMsg(alias)
 	}
 }
 
@@ -0,0 +1,316 @@
+package datastreamscreen
+
+import (
+	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
+	"esmon/tui/indexscreen"
+	"esmon/tui/styles"
+	"esmon/tui/tableselection"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// healthMarker precedes the health of a data stream. The table does not
+	// support styled cells, so the markers are colored after the table has
+	// been rendered.
+	healthMarker = "●"
+
+	timeFormat = "2006-01-02 15:04:05"
+)
+
+var (
+	defaultTheme = styles.GetTheme(nil)
+
+	dataStreamTableColumns []table.Column = []table.Column{
+		{Title: "↑Name [★]", Width: 20},
+		{Title: "Template", Width: 20},
+		{Title: "Generation", Width: 10},
+		{Title: "Backing indices", Width: 10},
+		{Title: "Storage size [*]", Width: 10},
+		{Title: "Max timestamp", Width: 20},
+		{Title: "Health", Width: 10},
+	}
+
+	dataStreamTableRows []table.Row
+
+	dataStreamTableStyles = table.DefaultStyles()
+
+	helpStyle           = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLightMuted)
+	helpSeparatorString = " • "
+
+	healthStyles = map[string]lipgloss.Style{
+		"GREEN":  lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusGreen),
+		"YELLOW": lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusYellow),
+		"RED":    lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusRed),
+	}
+
+	defaultKeyMap = keyMap{
+		backingIndices: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("<⏎>", "backing indices"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("<esc>", "back"),
+		),
+	}
+)
+
+// DataStreamMsg contains the data streams and the indices of the cluster,
+// from which the backing indices of a data stream are picked.
+type DataStreamMsg struct {
+	DataStreams []elasticsearch.DataStream
+	Indices     indexscreen.IndexMsg
+}
+
+type ErrorMsg error
+
+type keyMap struct {
+	backingIndices key.Binding
+	back           key.Binding
+}
+
+type Model struct {
+	width  int
+	height int
+
+	dataStreamTable table.Model
+	selection       tableselection.Selection
+
+	dataStreams []elasticsearch.DataStream
+	indices     indexscreen.IndexMsg
+
+	// the backing indices of the data stream replace the data stream list
+	// while they are shown, they are listed by an index screen
+	showBackingIndices bool
+	backingDataStream  string
+	backingIndexScreen indexscreen.Model
+
+	help help.Model
+
+	errorPanel errorpanel.Model
+}
+
+func New(theme *styles.Theme) Model {
+	m := Model{}
+
+	m.dataStreamTable = table.New(
+		table.WithColumns(dataStreamTableColumns),
+		table.WithRows(dataStreamTableRows),
+		table.WithFocused(true),
+	)
+
+	dataStreamTableStyles.Header = dataStreamTableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		BorderBottom(true).
+		Bold(false).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	dataStreamTableStyles.Selected = dataStreamTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted)).
+		Bold(false)
+	m.dataStreamTable.SetStyles(dataStreamTableStyles)
+
+	m.backingIndexScreen = indexscreen.New(theme)
+
+	m.help = help.New()
+	m.help.Styles = styles.HelpStyle
+
+	m.errorPanel = errorpanel.New(theme)
+
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		for index := range dataStreamTableColumns {
+			dataStreamTableColumns[index].Width = m.width/len(dataStreamTableColumns) - 2
+		}
+
+		m.dataStreamTable.SetHeight(m.height - 3)
+		m.dataStreamTable.SetColumns(dataStreamTableColumns)
+
+		// the line below the backing indices names the data stream
+		m.backingIndexScreen, cmd = m.backingIndexScreen.Update(tea.WindowSizeMsg{
+			Width: m.width, Height: m.height - 1,
+		})
+		cmds = append(cmds, cmd)
+
+		m.help.Width = m.width - 2
+
+	case styles.ThemeChangeMsg:
+		var theme = styles.Theme(msg)
+		setStyles(&theme)
+
+		m.dataStreamTable.SetStyles(dataStreamTableStyles)
+		m.help.Styles = styles.HelpStyle
+
+		m.backingIndexScreen, cmd = m.backingIndexScreen.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case tea.KeyMsg:
+		switch {
+		case m.showBackingIndices:
+			// the index screen goes back from the lifecycle of an index to
+			// the backing indices itself
+			if key.Matches(msg, defaultKeyMap.back) && !m.backingIndexScreen.ShowsLifecycle() {
+				m.showBackingIndices = false
+				m.backingDataStream = ""
+				break
+			}
+
+			m.backingIndexScreen, cmd = m.backingIndexScreen.Update(msg)
+			cmds = append(cmds, cmd)
+
+		case key.Matches(msg, defaultKeyMap.backingIndices):
+			selectedRow := m.dataStreamTable.SelectedRow()
+			if selectedRow == nil {
+				break
+			}
+
+			m.showBackingIndices = true
+			m.backingDataStream = selectedRow[0]
+			m.backingIndexScreen, cmd = m.backingIndexScreen.Update(m.backingIndices())
+			cmds = append(cmds, cmd)
+
+		default:
+			m.dataStreamTable, cmd = m.dataStreamTable.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
+
+	case DataStreamMsg:
+		m.errorPanel.SetError(nil)
+
+		m.dataStreams = msg.DataStreams
+		m.indices = msg.Indices
+
+		var (
+			dataStreamTableRows []table.Row
+			rowKeys             []string
+		)
+
+		for _, row := range msg.DataStreams {
+			maxTimestamp := ""
+			if row.Stats.MaximumTimestamp > 0 {
+				maxTimestamp = time.UnixMilli(row.Stats.MaximumTimestamp).Format(timeFormat)
+			}
+
+			rowKeys = append(rowKeys, row.Name)
+			dataStreamTableRows = append(dataStreamTableRows, table.Row{
+				row.Name,
+				row.Template,
+				fmt.Sprintf("%d", row.Generation),
+				fmt.Sprintf("%d", len(row.Indices)),
+				strings.ToUpper(row.Stats.StoreSize),
+				maxTimestamp,
+				fmt.Sprintf("%s %s", healthMarker, row.Status),
+			})
+		}
+
+		m.selection.SetRows(&m.dataStreamTable, dataStreamTableRows, rowKeys)
+
+		if m.showBackingIndices {
+			m.backingIndexScreen, cmd = m.backingIndexScreen.Update(m.backingIndices())
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	if m.showBackingIndices {
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			m.backingIndexScreen.View(),
+			helpStyle.Render(fmt.Sprintf("Backing indices of %s", m.backingDataStream))+
+				helpStyle.Render(helpSeparatorString)+
+				m.help.ShortHelpView([]key.Binding{defaultKeyMap.back}),
+		)
+	}
+
+	tableView := m.dataStreamTable.View()
+	for health, style := range healthStyles {
+		tableView = strings.ReplaceAll(
+			tableView,
+			fmt.Sprintf("%s %s", healthMarker, health),
+			fmt.Sprintf("%s %s", style.Render(healthMarker), health),
+		)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		tableView,
+		helpStyle.Render("[★] Sorting by name • [*] Total size (including replicas)")+
+			helpStyle.Render(helpSeparatorString)+
+			m.help.ShortHelpView([]key.Binding{defaultKeyMap.backingIndices}),
+	)
+}
+
+// backingIndices returns the indices of the data stream whose backing indices
+// are shown. Backing indices without stats (e.g. closed ones) are missing.
+func (m Model) backingIndices() indexscreen.IndexMsg {
+	backing := make(map[string]bool)
+	for _, dataStream := range m.dataStreams {
+		if dataStream.Name != m.backingDataStream {
+			continue
+		}
+		for _, name := range dataStream.BackingIndices() {
+			backing[name] = true
+		}
+	}
+
+	msg := m.indices
+	msg.Indices = nil
+	for _, index := range m.indices.Indices {
+		if backing[index.Name] {
+			msg.Indices = append(msg.Indices, index)
+		}
+	}
+
+	return msg
+}
+
+func setStyles(theme *styles.Theme) {
+	dataStreamTableStyles.Header = dataStreamTableStyles.Header.
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	dataStreamTableStyles.Selected = dataStreamTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+
+	healthStyles["GREEN"] = healthStyles["GREEN"].Foreground(lipgloss.Color(theme.BackgroundColorStatusGreen))
+	healthStyles["YELLOW"] = healthStyles["YELLOW"].Foreground(lipgloss.Color(theme.BackgroundColorStatusYellow))
+	healthStyles["RED"] = healthStyles["RED"].Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+}
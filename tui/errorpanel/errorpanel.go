@@ -0,0 +1,119 @@
+package errorpanel
+
+import (
+	"errors"
+	"esmon/elasticsearch"
+	"esmon/tui/styles"
+	"fmt"
+	"net/http"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	defaultTheme = styles.GetTheme(nil)
+
+	panelStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(defaultTheme.BackgroundColorStatusRed).
+			Foreground(defaultTheme.ForegroundColorLight).
+			Padding(0, 1)
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(defaultTheme.BackgroundColorStatusRed)
+	keyStyle   = lipgloss.NewStyle().Width(13).Foreground(defaultTheme.ForegroundColorLightMuted)
+	hintStyle  = lipgloss.NewStyle().MarginTop(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+)
+
+type Model struct {
+	width int
+
+	err error
+}
+
+func New(theme *styles.Theme) Model {
+	m := Model{}
+
+	setStyles(theme)
+
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case styles.ThemeChangeMsg:
+		var theme = styles.Theme(msg)
+		setStyles(&theme)
+	}
+
+	return m, nil
+}
+
+func (m *Model) SetError(err error) {
+	m.err = err
+}
+
+func (m Model) Err() error {
+	return m.err
+}
+
+func (m Model) View() string {
+	if m.err == nil {
+		return ""
+	}
+
+	// the width does not include the border
+	style := panelStyle.Copy().Width(max(m.width-2, 0))
+
+	var apiError *elasticsearch.APIError
+	if !errors.As(m.err, &apiError) {
+		return style.Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				titleStyle.Render("⚠ Error"),
+				m.err.Error(),
+			),
+		)
+	}
+
+	lines := []string{
+		titleStyle.Render(fmt.Sprintf("⚠ %d %s", apiError.StatusCode, http.StatusText(apiError.StatusCode))),
+	}
+
+	if apiError.Type != "" {
+		lines = append(lines, keyStyle.Render("Type:")+apiError.Type)
+	}
+	lines = append(lines, keyStyle.Render("Reason:")+apiError.Reason)
+
+	for index, rootCause := range apiError.RootCauses {
+		key := ""
+		if index == 0 {
+			key = "Root causes:"
+		}
+		lines = append(lines, keyStyle.Render(key)+fmt.Sprintf("%s: %s", rootCause.Type, rootCause.Reason))
+	}
+
+	switch apiError.StatusCode {
+	case http.StatusUnauthorized:
+		lines = append(lines, hintStyle.Render("Check the credentials configured for the cluster."))
+	case http.StatusForbidden:
+		lines = append(lines, hintStyle.Render("The user lacks the privileges required for this request (e.g. monitor)."))
+	}
+
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func setStyles(theme *styles.Theme) {
+	panelStyle = panelStyle.
+		BorderForeground(lipgloss.Color(theme.BackgroundColorStatusRed)).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	titleStyle = titleStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+	keyStyle = keyStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	hintStyle = hintStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+}
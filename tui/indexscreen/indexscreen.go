@@ -1,41 +1,131 @@
 package indexscreen
 
 import (
+	"bytes"
+	"encoding/json"
 	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
 	"esmon/tui/styles"
+	"esmon/tui/tablecells"
+	"esmon/tui/tablecolumns"
+	"esmon/tui/tableselection"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+const (
+	// failedStepMarker precedes the lifecycle step of an index whose policy
+	// failed to execute a step. The table does not support styled cells, so
+	// the markers are colored after the table has been rendered.
+	failedStepMarker = "▲"
+
+	nameColumnTitle = "Name"
+
+	// the name column is as wide as the longest index name, at least the
+	// minimum and at most half of the table
+	minNameWidth = 20
+)
+
+// lifecycleColumnTitles are the titles of the columns which are hidden for
+// clusters without ILM or ISM.
+var lifecycleColumnTitles = []string{"Phase", "Action", "Step", "Age"}
+
 var (
 	defaultTheme = styles.GetTheme(nil)
 
+	// the widths of the columns besides the name column are their share of
+	// the remaining width
 	indexTableColumns []table.Column = []table.Column{
-		{Title: "Name", Width: 20},
-		{Title: "Health", Width: 10},
-		{Title: "Status", Width: 10},
-		{Title: "Docs count [*]", Width: 20},
-		{Title: "↓Storage size [*]", Width: 20},
+		{Title: nameColumnTitle, Width: minNameWidth},
+		{Title: "Health", Width: 8},
+		{Title: "Status", Width: 8},
+		{Title: "Docs count [*]", Width: 14},
+		{Title: "↓Storage size [*]", Width: 14},
+		{Title: "Phase", Width: 8},
+		{Title: "Action", Width: 10},
+		{Title: "Step", Width: 16},
+		{Title: "Age", Width: 8},
+		{Title: "Index/s", Width: 9},
+		{Title: "Search/s", Width: 9},
+		{Title: "Get/s", Width: 9},
+		{Title: "Refresh/s", Width: 9},
+		{Title: "Merge/s", Width: 9},
 	}
 
 	indexTableRows []table.Row
 
 	indexTableStyles = table.DefaultStyles()
 
-	helpStyle = lipgloss.NewStyle().Height(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+	helpStyle           = lipgloss.NewStyle().Height(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+	failedStepStyle     = lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusRed)
+	summaryKeyStyle     = lipgloss.NewStyle().Width(18).Foreground(defaultTheme.ForegroundColorLightMuted)
+	summaryValueStyle   = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLight)
+	helpSeparatorString = " • "
+
+	defaultKeyMap = keyMap{
+		lifecycle: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("<⏎>", "lifecycle"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("<esc>", "back"),
+		),
+	}
 )
 
-type IndexMsg []elasticsearch.IndexStats
+type IndexMsg struct {
+	Indices []elasticsearch.IndexStats
+
+	// the operation rates by index name, indices without previous stats have
+	// none
+	Rates map[string]elasticsearch.OperationRates
+
+	// the lifecycle state by index name, unmanaged indices have none
+	Lifecycles map[string]elasticsearch.IndexLifecycle
+
+	// the lifecycle columns are hidden if the version supports neither ILM
+	// nor ISM
+	Version elasticsearch.Version
+}
+
+type ErrorMsg error
+
+type keyMap struct {
+	lifecycle key.Binding
+	back      key.Binding
+}
 
 type Model struct {
 	width  int
 	height int
 
 	indexTable table.Model
+	selection  tableselection.Selection
+
+	// the width of the longest index name
+	nameWidth int
+
+	supportsLifecycles bool
+
+	lifecycles map[string]elasticsearch.IndexLifecycle
+
+	// the lifecycle of the index replaces the index list while it is shown
+	showLifecycle     bool
+	lifecycleIndex    string
+	lifecycleViewport viewport.Model
+
+	help help.Model
+
+	errorPanel errorpanel.Model
 }
 
 func New(theme *styles.Theme) Model {
@@ -58,6 +148,13 @@ func New(theme *styles.Theme) Model {
 		Bold(false)
 	m.indexTable.SetStyles(indexTableStyles)
 
+	m.lifecycleViewport = viewport.New(0, 0)
+
+	m.help = help.New()
+	m.help.Styles = styles.HelpStyle
+
+	m.errorPanel = errorpanel.New(theme)
+
 	return m
 }
 
@@ -75,52 +172,235 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
 
-		for index := range indexTableColumns {
-			indexTableColumns[index].Width = m.width/len(indexTableColumns) - 2
-		}
-
 		m.indexTable.SetHeight(m.height - 3)
-		m.indexTable.SetColumns(indexTableColumns)
+		m.indexTable.SetColumns(m.columns())
 
-		helpStyle.Width(m.width - 2)
+		m.lifecycleViewport.Width = m.width
+		m.lifecycleViewport.Height = m.height - 1
+		m.lifecycleViewport.SetContent(m.renderLifecycle())
+
+		m.help.Width = m.width - 2
 
 	case styles.ThemeChangeMsg:
 		var theme = styles.Theme(msg)
 		setStyles(&theme)
 
 		m.indexTable.SetStyles(indexTableStyles)
+		m.help.Styles = styles.HelpStyle
+		m.lifecycleViewport.SetContent(m.renderLifecycle())
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, defaultKeyMap.back) && m.showLifecycle:
+			m.showLifecycle = false
+			m.lifecycleIndex = ""
+
+		case key.Matches(msg, defaultKeyMap.lifecycle) && !m.showLifecycle && m.supportsLifecycles:
+			selectedRow := m.indexTable.SelectedRow()
+			if selectedRow == nil {
+				break
+			}
+
+			m.showLifecycle = true
+			m.lifecycleIndex = selectedRow[0]
+			m.lifecycleViewport.SetContent(m.renderLifecycle())
+			m.lifecycleViewport.GotoTop()
+		}
+
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
 
 	case IndexMsg:
-		var indexTableRows []table.Row
+		m.errorPanel.SetError(nil)
+
+		var (
+			indexTableRows []table.Row
+			rowKeys        []string
+		)
+		nameWidth := 0
+		supportsLifecycles := msg.Version.Supports(elasticsearch.IndexLifecycleCapability) ||
+			msg.Version.Supports(elasticsearch.IndexStateManagementCapability)
 
-		for _, row := range msg {
-			indexTableRows = append(indexTableRows, table.Row{
+		for _, row := range msg.Indices {
+			indexTableRow := table.Row{
 				row.Name,
 				row.Health,
 				row.Status,
 				fmt.Sprintf("%d", row.Total.Docs.Count),
 				strings.ToUpper(row.Total.Store.Size),
-			})
+			}
+
+			if supportsLifecycles {
+				lifecycle, managed := msg.Lifecycles[row.Name]
+				indexTableRow = append(indexTableRow, lifecycleState(lifecycle, managed)...)
+			}
+
+			rates, ok := msg.Rates[row.Name]
+			indexTableRow = append(indexTableRow, tablecells.OperationRates(rates, ok)...)
+
+			nameWidth = max(nameWidth, lipgloss.Width(row.Name))
+
+			rowKeys = append(rowKeys, row.Name)
+			indexTableRows = append(indexTableRows, indexTableRow)
 		}
 
-		m.indexTable.SetRows(indexTableRows)
+		// rows are cleared first as the table renders the current rows with
+		// the new columns
+		m.lifecycles = msg.Lifecycles
+		m.nameWidth = nameWidth
+		m.supportsLifecycles = supportsLifecycles
+		m.indexTable.SetRows(nil)
+		m.indexTable.SetColumns(m.columns())
+		m.selection.SetRows(&m.indexTable, indexTableRows, rowKeys)
+		m.lifecycleViewport.SetContent(m.renderLifecycle())
 
 	}
 
-	m.indexTable, cmd = m.indexTable.Update(msg)
+	if m.showLifecycle {
+		m.lifecycleViewport, cmd = m.lifecycleViewport.Update(msg)
+	} else {
+		m.indexTable, cmd = m.indexTable.Update(msg)
+	}
+	cmds = append(cmds, cmd)
+
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
 	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	if m.showLifecycle {
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			m.lifecycleViewport.View(),
+			m.help.ShortHelpView([]key.Binding{defaultKeyMap.back}),
+		)
+	}
+
+	if !m.supportsLifecycles {
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			m.indexTable.View(),
+			helpStyle.Render("[★] Total size (including replicas)"),
+		)
+	}
+
+	tableView := strings.ReplaceAll(
+		m.indexTable.View(),
+		failedStepMarker,
+		failedStepStyle.Render(failedStepMarker),
+	)
+
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
-		m.indexTable.View(),
-		helpStyle.Render("[★] Total size (including replicas)"),
+		tableView,
+		helpStyle.Render(fmt.Sprintf("[★] Total size (including replicas) • %s Failed lifecycle step", failedStepMarker))+
+			helpStyle.Render(helpSeparatorString)+
+			m.help.ShortHelpView([]key.Binding{defaultKeyMap.lifecycle}),
 	)
 }
 
+// columns returns the columns fitting the width. The name column is sized
+// first, so index names are not truncated unless they take up more than half
+// of the table.
+func (m Model) columns() []table.Column {
+	var columns []table.Column
+	for _, column := range indexTableColumns {
+		if !m.supportsLifecycles && slices.Contains(lifecycleColumnTitles, column.Title) {
+			continue
+		}
+		columns = append(columns, column)
+	}
+
+	return tablecolumns.Fit(columns, m.width, map[string]int{
+		nameColumnTitle: max(min(m.nameWidth, m.width/2), minNameWidth),
+	})
+}
+
+// ShowsLifecycle reports whether the lifecycle of an index replaces the index
+// list.
+func (m Model) ShowsLifecycle() bool {
+	return m.showLifecycle
+}
+
+// lifecycleState returns the cells of the lifecycle columns, which are empty
+// for unmanaged indices. A failed step is shown as ERROR followed by the step
+// which failed.
+func lifecycleState(lifecycle elasticsearch.IndexLifecycle, managed bool) []string {
+	if !managed {
+		return []string{"", "", "", ""}
+	}
+
+	step := lifecycle.Step
+	if lifecycle.Failed {
+		step = fmt.Sprintf("%s ERROR (%s)", failedStepMarker, lifecycle.FailedStep)
+	}
+
+	return []string{lifecycle.Phase, lifecycle.Action, step, lifecycle.Age}
+}
+
+// renderLifecycle renders the lifecycle state of the index whose lifecycle is
+// shown, including the step info of a failed step and the definition of the
+// current phase.
+func (m Model) renderLifecycle() string {
+	if !m.showLifecycle {
+		return ""
+	}
+
+	lifecycle, managed := m.lifecycles[m.lifecycleIndex]
+	if !managed {
+		return helpStyle.Render(fmt.Sprintf("%s is not managed by a lifecycle policy", m.lifecycleIndex))
+	}
+
+	summary := [][]string{
+		{"Index:", lifecycle.Index},
+		{"Policy:", lifecycle.Policy},
+		{"Phase:", lifecycle.Phase},
+		{"Action:", lifecycle.Action},
+		{"Step:", lifecycle.Step},
+		{"Failed step:", lifecycle.FailedStep},
+		{"Age:", lifecycle.Age},
+		{"Step info:", formatJson(lifecycle.StepInfo)},
+		{"Phase definition:", formatJson(lifecycle.PhaseDefinition)},
+	}
+
+	summaryValueWidth := max(m.width-summaryKeyStyle.GetWidth(), 0)
+
+	var lines []string
+	for _, row := range summary {
+		if row[1] == "" {
+			continue
+		}
+
+		valueStyle := summaryValueStyle
+		if lifecycle.Failed && (row[0] == "Step:" || row[0] == "Failed step:" || row[0] == "Step info:") {
+			valueStyle = failedStepStyle
+		}
+
+		lines = append(lines, lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			summaryKeyStyle.Render(row[0]),
+			valueStyle.Copy().Width(summaryValueWidth).Render(row[1]),
+		))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatJson indents a JSON value, null and empty values are omitted.
+func formatJson(value json.RawMessage) string {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, value, "", "  "); err != nil || indented.String() == "null" {
+		return ""
+	}
+	return indented.String()
+}
+
 func setStyles(theme *styles.Theme) {
 	indexTableStyles.Header = indexTableStyles.Header.
 		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
@@ -129,4 +409,7 @@ func setStyles(theme *styles.Theme) {
 		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
 
 	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	failedStepStyle = failedStepStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+	summaryKeyStyle = summaryKeyStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	summaryValueStyle = summaryValueStyle.Foreground(lipgloss.Color(theme.ForegroundColorLight))
 }
@@ -2,26 +2,70 @@ package nodescreen
 
 import (
 	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
 	"esmon/tui/styles"
+	"esmon/tui/tablecells"
+	"esmon/tui/tablecolumns"
+	"esmon/tui/tableselection"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+const (
+	nameColumnTitle     = "↑Name"
+	shardsColumnTitle   = "Shards"
+	diskColumnTitle     = "Disk usage [%]"
+	breakersColumnTitle = "Tripped breakers"
+
+	// the name and breakers columns are as wide as their longest cell within
+	// these bounds, the disk usage column fits the highest watermark
+	minNameWidth     = 12
+	maxNameWidth     = 30
+	diskWidth        = 14
+	minBreakersWidth = 16
+	maxBreakersWidth = 40
+
+	// heapMarker precedes the heap usage above the warning threshold, which
+	// is colored as well
+	heapMarker = "●"
+
+	heapWarningPercent  = 75
+	heapCriticalPercent = 90
+
+	// diskMarker precedes the highest disk watermark crossed by a node, it is
+	// colored like the heap marker
+	diskMarker = "●"
+)
+
 var (
 	defaultTheme = styles.GetTheme(nil)
 
+	// the widths of the columns besides the name, disk usage and breakers
+	// columns are their share of the remaining width
 	nodeTableColumns []table.Column = []table.Column{
-		{Title: "↑Name", Width: 20},
+		{Title: nameColumnTitle, Width: minNameWidth},
 		{Title: "Transport", Width: 20},
-		{Title: "Shards", Width: 20},
+		{Title: shardsColumnTitle, Width: 8},
 		{Title: "CPU usage [%]", Width: 10},
 		{Title: "Load average", Width: 10},
 		{Title: "MEM usage", Width: 10},
 		{Title: "Free disk space", Width: 10},
+		{Title: diskColumnTitle, Width: diskWidth},
+		{Title: "Heap [%]", Width: 10},
+		{Title: "Old GCs", Width: 10},
+		{Title: "Old GC time", Width: 10},
+		{Title: breakersColumnTitle, Width: minBreakersWidth},
+		{Title: "Index/s", Width: 10},
+		{Title: "Search/s", Width: 10},
+		{Title: "Get/s", Width: 10},
+		{Title: "Refresh/s", Width: 10},
+		{Title: "Merge/s", Width: 10},
 	}
 
 	nodeTableRows []table.Row
@@ -29,11 +73,46 @@ var (
 	nodeTableStyles = table.DefaultStyles()
 
 	helpStyle = lipgloss.NewStyle().Height(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+
+	heapWarningStyle  = lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusYellow)
+	heapCriticalStyle = lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusRed)
+
+	diskWatermarkStyles = map[elasticsearch.DiskWatermarkLevel]lipgloss.Style{
+		elasticsearch.LowDiskWatermark:        lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusYellow),
+		elasticsearch.HighDiskWatermark:       lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorHighlighted),
+		elasticsearch.FloodStageDiskWatermark: lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusRed),
+	}
 )
 
 type NodeMsg struct {
 	Nodes      []elasticsearch.NodeStats
 	MasterNode *elasticsearch.NodeStats
+	Version    elasticsearch.Version
+
+	// the disk usage is not compared to the watermarks if they are unknown
+	DiskWatermarks *elasticsearch.DiskWatermarks
+
+	// the operation rates by node ID, nodes without previous stats have none
+	Rates map[string]elasticsearch.OperationRates
+}
+
+type ErrorMsg error
+
+// ActivatedMsg is sent when the screen is shown. The screen only receives
+// data while it is shown, so the collections and tripped breakers are counted
+// from the next refresh on instead of covering the time the screen was hidden.
+type ActivatedMsg struct{}
+
+type oldGc struct {
+	count        int64
+	timeInMillis int64
+}
+
+// cellLevels are the levels of a row which its heap and disk usage cells are
+// colored by
+type cellLevels struct {
+	heapPercent int
+	diskLevel   elasticsearch.DiskWatermarkLevel
 }
 
 type Model struct {
@@ -41,10 +120,32 @@ type Model struct {
 	height int
 
 	nodeTable table.Model
+	selection tableselection.Selection
+
+	// the shard count is only provided by newer versions
+	showShards bool
+
+	// the widths of the longest name and tripped breakers cells
+	nameWidth     int
+	breakersWidth int
+
+	// the old generation garbage collections of the previous refresh by node
+	// ID, the collections since then are displayed
+	oldGcs map[string]oldGc
+	// the trip counts of the circuit breakers of the previous refresh by node
+	// ID and breaker name, the trips since then are displayed
+	trippedBreakers map[string]map[string]int64
+
+	// the levels of each row and the columns of the heap and disk usage
+	levels                 []cellLevels
+	heapColumn, diskColumn int
+
+	errorPanel errorpanel.Model
 }
 
 func New(theme *styles.Theme) Model {
 	m := Model{}
+	m.showShards = true
 
 	m.nodeTable = table.New(
 		table.WithColumns(nodeTableColumns),
@@ -63,6 +164,8 @@ func New(theme *styles.Theme) Model {
 		Bold(false)
 	m.nodeTable.SetStyles(nodeTableStyles)
 
+	m.errorPanel = errorpanel.New(theme)
+
 	return m
 }
 
@@ -80,12 +183,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
 
-		for index := range nodeTableColumns {
-			nodeTableColumns[index].Width = m.width/len(nodeTableColumns) - 2
-		}
-
 		m.nodeTable.SetHeight(m.height - 3)
-		m.nodeTable.SetColumns(nodeTableColumns)
+		m.nodeTable.SetColumns(m.columns())
 
 		helpStyle.Width(m.width - 2)
 
@@ -95,44 +194,198 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		m.nodeTable.SetStyles(nodeTableStyles)
 
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
+
+	case ActivatedMsg:
+		m.oldGcs = nil
+		m.trippedBreakers = nil
+
 	case NodeMsg:
-		var nodeTableRows []table.Row
+		m.errorPanel.SetError(nil)
+
+		var (
+			nodeTableRows []table.Row
+			rowKeys       []string
+			levels        []cellLevels
+		)
+		oldGcs := make(map[string]oldGc, len(msg.Nodes))
+		trippedBreakers := make(map[string]map[string]int64, len(msg.Nodes))
+		heapColumn, diskColumn := 0, 0
+		nameWidth, breakersWidth := 0, 0
 
 		for _, row := range msg.Nodes {
 			nodeName := row.Name
-			if row.Id == msg.MasterNode.Id {
+			if msg.MasterNode != nil && row.Id == msg.MasterNode.Id {
 				nodeName += "[★]"
 			}
 
-			nodeTableRows = append(nodeTableRows, table.Row{
+			nodeTableRow := table.Row{
 				nodeName,
 				row.TransportAddress,
-				fmt.Sprintf("%d", row.Indices.ShardStats.TotalCount),
+			}
+			if msg.Version.Supports(elasticsearch.ShardStatsCapability) {
+				nodeTableRow = append(nodeTableRow, fmt.Sprintf("%d", row.Indices.ShardStats.TotalCount))
+			}
+			nodeTableRow = append(nodeTableRow,
 				fmt.Sprintf("%d", row.Os.CPU.Percent),
 				fmt.Sprintf("%.2f", row.Os.CPU.LoadAverage.One5M),
 				strings.ToUpper(row.Os.Mem.Used),
 				strings.ToUpper(row.Fs.Total.Free),
-			})
+			)
+
+			level := elasticsearch.NoDiskWatermark
+			if msg.DiskWatermarks != nil {
+				level = msg.DiskWatermarks.Level(row)
+			}
+			diskColumn, heapColumn = len(nodeTableRow), len(nodeTableRow)+1
+			levels = append(levels, cellLevels{heapPercent: row.Jvm.Mem.HeapUsedPercent, diskLevel: level})
+			nodeTableRow = append(nodeTableRow,
+				diskUsage(row, level),
+				heapUsage(row.Jvm.Mem.HeapUsedPercent),
+			)
+
+			collectors := row.Jvm.Gc.Collectors
+			oldGcs[row.Id] = oldGc{
+				count:        collectors.Old.CollectionCount,
+				timeInMillis: collectors.Old.CollectionTimeInMillis,
+			}
+			if previous, ok := m.oldGcs[row.Id]; ok && collectors.Old.CollectionCount >= previous.count {
+				nodeTableRow = append(nodeTableRow,
+					fmt.Sprintf("%d", collectors.Old.CollectionCount-previous.count),
+					(time.Duration(collectors.Old.CollectionTimeInMillis-previous.timeInMillis) * time.Millisecond).String(),
+				)
+			} else {
+				// the first refresh and a restarted node have no previous
+				// collections
+				nodeTableRow = append(nodeTableRow, "", "")
+			}
+
+			trippedBreakers[row.Id] = make(map[string]int64, len(row.Breakers))
+			for name, breaker := range row.Breakers {
+				trippedBreakers[row.Id][name] = breaker.Tripped
+			}
+			breakers := breakersTripped(row, m.trippedBreakers[row.Id])
+			nodeTableRow = append(nodeTableRow, breakers)
+
+			nameWidth = max(nameWidth, lipgloss.Width(nodeName))
+			breakersWidth = max(breakersWidth, lipgloss.Width(breakers))
+
+			rates, ok := msg.Rates[row.Id]
+			nodeTableRow = append(nodeTableRow, tablecells.OperationRates(rates, ok)...)
+
+			rowKeys = append(rowKeys, row.Id)
+			nodeTableRows = append(nodeTableRows, nodeTableRow)
 		}
 
-		m.nodeTable.SetRows(nodeTableRows)
+		// rows are cleared first as the table renders the current rows with
+		// the new columns
+		m.oldGcs = oldGcs
+		m.trippedBreakers = trippedBreakers
+		m.levels, m.heapColumn, m.diskColumn = levels, heapColumn, diskColumn
+		m.showShards = msg.Version.Supports(elasticsearch.ShardStatsCapability)
+		m.nameWidth, m.breakersWidth = nameWidth, breakersWidth
+		m.nodeTable.SetRows(nil)
+		m.nodeTable.SetColumns(m.columns())
+		m.selection.SetRows(&m.nodeTable, nodeTableRows, rowKeys)
 
 	}
 
 	m.nodeTable, cmd = m.nodeTable.Update(msg)
 	cmds = append(cmds, cmd)
 
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
 	return m, tea.Batch(cmds...)
 }
 
+// columns returns the columns fitting the width. The name, disk usage and
+// breakers columns are sized first, so the master marker, the disk watermark
+// and the breakers are not truncated.
+func (m Model) columns() []table.Column {
+	var columns []table.Column
+	for _, column := range nodeTableColumns {
+		if column.Title == shardsColumnTitle && !m.showShards {
+			continue
+		}
+		columns = append(columns, column)
+	}
+
+	return tablecolumns.Fit(columns, m.width, map[string]int{
+		nameColumnTitle:     min(max(m.nameWidth, minNameWidth), maxNameWidth),
+		diskColumnTitle:     diskWidth,
+		breakersColumnTitle: min(max(m.breakersWidth, minBreakersWidth), maxBreakersWidth),
+	})
+}
+
 func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	tableView := tablecells.View(m.nodeTable, m.columns(), nodeTableStyles, func(row, column int) (lipgloss.Style, bool) {
+		if row >= len(m.levels) {
+			return lipgloss.Style{}, false
+		}
+
+		levels := m.levels[row]
+		switch {
+		case column == m.heapColumn && levels.heapPercent > heapCriticalPercent:
+			return heapCriticalStyle, true
+		case column == m.heapColumn && levels.heapPercent > heapWarningPercent:
+			return heapWarningStyle, true
+		case column == m.diskColumn && levels.diskLevel != elasticsearch.NoDiskWatermark:
+			return diskWatermarkStyles[levels.diskLevel], true
+		}
+		return lipgloss.Style{}, false
+	})
+
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
-		m.nodeTable.View(),
-		helpStyle.Render("[★] Master node"),
+		tableView,
+		helpStyle.Render("[★] Master node. Old GCs, their time and tripped breakers since the previous refresh"),
 	)
 }
 
+func heapUsage(percent int) string {
+	if percent > heapWarningPercent {
+		return fmt.Sprintf("%s %d", heapMarker, percent)
+	}
+	return fmt.Sprintf("%d", percent)
+}
+
+// diskUsage returns the used disk percentage followed by the highest disk
+// watermark crossed.
+func diskUsage(nodeStats elasticsearch.NodeStats, level elasticsearch.DiskWatermarkLevel) string {
+	usage := fmt.Sprintf("%.0f", elasticsearch.UsedDiskPercent(nodeStats.Fs.Total.TotalInBytes, nodeStats.Fs.Total.AvailableInBytes))
+	if level != elasticsearch.NoDiskWatermark {
+		return fmt.Sprintf("%s %s %s", usage, diskMarker, level)
+	}
+	return usage
+}
+
+// breakersTripped lists the circuit breakers which tripped since the previous
+// refresh with their trip count. It is empty on the first refresh and for a
+// restarted node, which have no previous trip counts.
+func breakersTripped(nodeStats elasticsearch.NodeStats, previous map[string]int64) string {
+	if previous == nil {
+		return ""
+	}
+
+	var breakers []string
+	for name, breaker := range nodeStats.Breakers {
+		if breaker.Tripped < previous[name] {
+			return ""
+		}
+		if tripped := breaker.Tripped - previous[name]; tripped > 0 {
+			breakers = append(breakers, fmt.Sprintf("%s: %d", name, tripped))
+		}
+	}
+	sort.Strings(breakers)
+	return strings.Join(breakers, ", ")
+}
+
 func setStyles(theme *styles.Theme) {
 	nodeTableStyles.Header = nodeTableStyles.Header.
 		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
@@ -141,4 +394,14 @@ func setStyles(theme *styles.Theme) {
 		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
 
 	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+
+	heapWarningStyle = heapWarningStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusYellow))
+	heapCriticalStyle = heapCriticalStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+
+	diskWatermarkStyles[elasticsearch.LowDiskWatermark] = diskWatermarkStyles[elasticsearch.LowDiskWatermark].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusYellow))
+	diskWatermarkStyles[elasticsearch.HighDiskWatermark] = diskWatermarkStyles[elasticsearch.HighDiskWatermark].
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+	diskWatermarkStyles[elasticsearch.FloodStageDiskWatermark] = diskWatermarkStyles[elasticsearch.FloodStageDiskWatermark].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
 }
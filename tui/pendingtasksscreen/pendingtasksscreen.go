@@ -0,0 +1,160 @@
+package pendingtasksscreen
+
+import (
+	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
+	"esmon/tui/styles"
+	"esmon/tui/tableselection"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	defaultTheme = styles.GetTheme(nil)
+
+	pendingTaskTableColumns []table.Column = []table.Column{
+		{Title: "Priority", Width: 10},
+		{Title: "Source", Width: 40},
+		{Title: "↓Time in queue [★]", Width: 20},
+		{Title: "Executing", Width: 10},
+		{Title: "Insert order", Width: 10},
+	}
+
+	pendingTaskTableRows []table.Row
+
+	pendingTaskTableStyles = table.DefaultStyles()
+
+	helpStyle = lipgloss.NewStyle().Height(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+)
+
+type PendingTaskMsg []elasticsearch.PendingTask
+type ErrorMsg error
+
+type Model struct {
+	width  int
+	height int
+
+	pendingTaskTable table.Model
+	selection        tableselection.Selection
+
+	errorPanel errorpanel.Model
+}
+
+func New(theme *styles.Theme) Model {
+	m := Model{}
+
+	m.pendingTaskTable = table.New(
+		table.WithColumns(pendingTaskTableColumns),
+		table.WithRows(pendingTaskTableRows),
+		table.WithFocused(true),
+	)
+
+	pendingTaskTableStyles.Header = pendingTaskTableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		BorderBottom(true).
+		Bold(false).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	pendingTaskTableStyles.Selected = pendingTaskTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted)).
+		Bold(false)
+	m.pendingTaskTable.SetStyles(pendingTaskTableStyles)
+
+	m.errorPanel = errorpanel.New(theme)
+
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		for index := range pendingTaskTableColumns {
+			pendingTaskTableColumns[index].Width = m.width/len(pendingTaskTableColumns) - 2
+		}
+
+		m.pendingTaskTable.SetHeight(m.height - 3)
+		m.pendingTaskTable.SetColumns(pendingTaskTableColumns)
+
+		helpStyle.Width(m.width - 2)
+
+	case styles.ThemeChangeMsg:
+		var theme = styles.Theme(msg)
+		setStyles(&theme)
+
+		m.pendingTaskTable.SetStyles(pendingTaskTableStyles)
+
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
+
+	case PendingTaskMsg:
+		m.errorPanel.SetError(nil)
+
+		var (
+			pendingTaskTableRows []table.Row
+			rowKeys              []string
+		)
+
+		for _, row := range msg {
+			executing := ""
+			if row.Executing {
+				executing = "yes"
+			}
+
+			rowKeys = append(rowKeys, fmt.Sprintf("%d", row.InsertOrder))
+			pendingTaskTableRows = append(pendingTaskTableRows, table.Row{
+				row.Priority,
+				row.Source,
+				row.TimeInQueue,
+				executing,
+				fmt.Sprintf("%d", row.InsertOrder),
+			})
+		}
+
+		m.selection.SetRows(&m.pendingTaskTable, pendingTaskTableRows, rowKeys)
+
+	}
+
+	m.pendingTaskTable, cmd = m.pendingTaskTable.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		m.pendingTaskTable.View(),
+		helpStyle.Render("[★] Sorting by time in queue, the longest waiting task first"),
+	)
+}
+
+func setStyles(theme *styles.Theme) {
+	pendingTaskTableStyles.Header = pendingTaskTableStyles.Header.
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	pendingTaskTableStyles.Selected = pendingTaskTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+}
@@ -2,41 +2,95 @@ package relocatingshardsscreen
 
 import (
 	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
 	"esmon/tui/styles"
+	"esmon/tui/tableselection"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// recoveryHistorySize is the number of fetches of a recovery the throughput is
+// computed from. A longer history smooths the throughput.
+const recoveryHistorySize = 6
+
 var (
 	defaultTheme = styles.GetTheme(nil)
 
 	shardTableColumns []table.Column = []table.Column{
 		{Title: "Index", Width: 20},
 		{Title: "Shard", Width: 10},
+		{Title: "Type", Width: 10},
+		{Title: "Stage", Width: 10},
 		{Title: "Source", Width: 20},
 		{Title: "Target", Width: 20},
 		{Title: "Progress [%]", Width: 20},
 		{Title: "↓Time", Width: 10},
+		{Title: "MB/s", Width: 10},
+		{Title: "Files/s", Width: 10},
+		{Title: "ETA", Width: 10},
+		{Title: "Throttled", Width: 10},
 	}
 
 	shardTableRows []table.Row
 
 	shardTableStyles = table.DefaultStyles()
 
-	helpStyle = lipgloss.NewStyle().Height(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+	helpStyle           = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLightMuted)
+	helpSeparatorString = " • "
+
+	defaultKeyMap = keyMap{
+		filter: key.NewBinding(
+			key.WithKeys("P"),
+		),
+	}
 )
 
-type ShardMsg []elasticsearch.Recovery
+type ShardMsg struct {
+	Recoveries []elasticsearch.Recovery
+	// the throughput is computed from the recoveries of consecutive fetches
+	FetchedAt time.Time
+	// the recovery bandwidth limit of each node, empty if it is unknown
+	MaxBytesPerSec string
+}
+
+type ErrorMsg error
+
+// recoverySample is the progress of a recovery at a fetch.
+type recoverySample struct {
+	time           time.Time
+	recoveredBytes int
+	recoveredFiles int
+}
+
+type keyMap struct {
+	filter key.Binding
+}
 
 type Model struct {
 	width  int
 	height int
 
 	shardTable table.Model
+	selection  tableselection.Selection
+
+	// the recoveries are filtered when the rows are set
+	recoveries     []elasticsearch.Recovery
+	peerOnly       bool
+	maxBytesPerSec string
+
+	// the latest samples of every recovery by row key, oldest first
+	history map[string][]recoverySample
+
+	help help.Model
+
+	errorPanel errorpanel.Model
 }
 
 func New(theme *styles.Theme) Model {
@@ -59,6 +113,11 @@ func New(theme *styles.Theme) Model {
 		Bold(false)
 	m.shardTable.SetStyles(shardTableStyles)
 
+	m.help = help.New()
+	m.help.Styles = styles.HelpStyle
+
+	m.errorPanel = errorpanel.New(theme)
+
 	return m
 }
 
@@ -83,56 +142,193 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.shardTable.SetHeight(m.height - 3)
 		m.shardTable.SetColumns(shardTableColumns)
 
-		helpStyle.Width(m.width - 2)
+		m.help.Width = m.width - 2
 
 	case styles.ThemeChangeMsg:
 		var theme = styles.Theme(msg)
 		setStyles(&theme)
 
 		m.shardTable.SetStyles(shardTableStyles)
+		m.help.Styles = styles.HelpStyle
+
+	case tea.KeyMsg:
+		// the table binds most lowercase keys, the filter key is not
+		// passed on to it
+		if key.Matches(msg, defaultKeyMap.filter) {
+			m.peerOnly = !m.peerOnly
+			m.setRows()
+			return m, nil
+		}
 
-	case ShardMsg:
-		var shardTableRows []table.Row
-
-		for _, row := range msg {
-			shard := fmt.Sprint(row.ID)
-			if row.Primary {
-				shard += "[P]"
-			} else {
-				shard += "[R]"
-			}
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
 
-			shardTableRows = append(shardTableRows, table.Row{
-				row.Index.Name,
-				shard,
-				row.Source.Peer.PeerName(),
-				row.Target.Peer.PeerName(),
-				fmt.Sprintf(
-					"%s (%s/%s)",
-					strings.TrimSuffix(row.Index.Size.Percent, "%"),
-					strings.ToUpper(row.Index.Size.Recovered),
-					strings.ToUpper(row.Index.Size.Total),
-				),
-				row.TotalTime,
-			})
-		}
+	case ShardMsg:
+		m.errorPanel.SetError(nil)
 
-		m.shardTable.SetRows(shardTableRows)
+		m.recoveries = msg.Recoveries
+		m.maxBytesPerSec = msg.MaxBytesPerSec
+		m.history = m.updateHistory(msg)
+		m.setRows()
 
 	}
 
 	m.shardTable, cmd = m.shardTable.Update(msg)
 	cmds = append(cmds, cmd)
 
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	filter := defaultKeyMap.filter
+	if m.peerOnly {
+		filter.SetHelp("<P>", "show all recoveries")
+	} else {
+		filter.SetHelp("<P>", "show peer recoveries only")
+	}
+
+	limit := ""
+	if m.maxBytesPerSec != "" {
+		limit = fmt.Sprintf("Recovery limit per node: %s/s • ", m.maxBytesPerSec)
+	}
+
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
 		m.shardTable.View(),
-		helpStyle.Render("[P] Primary shard • [R] Replica shard"),
+		helpStyle.Render(limit+"[P] Primary shard • [R] Replica shard • Throttled on source/target")+
+			helpStyle.Render(helpSeparatorString)+
+			m.help.ShortHelpView([]key.Binding{filter}),
+	)
+}
+
+// setRows sets the rows of the recoveries which pass the filter.
+func (m *Model) setRows() {
+	var (
+		shardTableRows []table.Row
+		rowKeys        []string
 	)
+
+	for _, row := range m.recoveries {
+		if m.peerOnly && row.Type != elasticsearch.RecoveryTypePeer {
+			continue
+		}
+
+		shard := fmt.Sprint(row.ID)
+		if row.Primary {
+			shard += "[P]"
+		} else {
+			shard += "[R]"
+		}
+
+		bytesPerSecond, filesPerSecond, eta := "", "", ""
+		if bytesRate, filesRate, ok := throughput(m.history[recoveryKey(row)]); ok {
+			bytesPerSecond = fmt.Sprintf("%.1f", bytesRate/(1<<20))
+			filesPerSecond = fmt.Sprintf("%.1f", filesRate)
+
+			remainingBytes := row.Index.Size.TotalInBytes - row.Index.Size.ReusedInBytes - row.Index.Size.RecoveredInBytes
+			if bytesRate > 0 && remainingBytes > 0 {
+				eta = (time.Duration(float64(remainingBytes)/bytesRate) * time.Second).String()
+			}
+		}
+
+		rowKeys = append(rowKeys, recoveryKey(row))
+		shardTableRows = append(shardTableRows, table.Row{
+			row.Index.Name,
+			shard,
+			humanize(row.Type),
+			humanize(row.Stage),
+			source(row),
+			row.Target.PeerName(),
+			fmt.Sprintf(
+				"%s (%s/%s)",
+				strings.TrimSuffix(row.Index.Size.Percent, "%"),
+				strings.ToUpper(row.Index.Size.Recovered),
+				strings.ToUpper(row.Index.Size.Total),
+			),
+			row.TotalTime,
+			bytesPerSecond,
+			filesPerSecond,
+			eta,
+			fmt.Sprintf(
+				"%s/%s",
+				time.Duration(row.Index.SourceThrottleTimeInMillis)*time.Millisecond,
+				time.Duration(row.Index.TargetThrottleTimeInMillis)*time.Millisecond,
+			),
+		})
+	}
+
+	m.selection.SetRows(&m.shardTable, shardTableRows, rowKeys)
+}
+
+// updateHistory adds the progress of the fetched recoveries to their history.
+// The history of finished recoveries is dropped.
+func (m Model) updateHistory(msg ShardMsg) map[string][]recoverySample {
+	history := make(map[string][]recoverySample, len(msg.Recoveries))
+
+	for _, recovery := range msg.Recoveries {
+		key := recoveryKey(recovery)
+		samples := m.history[key]
+
+		if len(samples) == 0 || msg.FetchedAt.After(samples[len(samples)-1].time) {
+			samples = append(samples, recoverySample{
+				time:           msg.FetchedAt,
+				recoveredBytes: recovery.Index.Size.RecoveredInBytes,
+				recoveredFiles: recovery.Index.Files.Recovered,
+			})
+		}
+		if len(samples) > recoveryHistorySize {
+			samples = samples[len(samples)-recoveryHistorySize:]
+		}
+
+		history[key] = samples
+	}
+
+	return history
+}
+
+// throughput returns the recovered bytes and files per second between the
+// oldest and the latest sample.
+func throughput(samples []recoverySample) (float64, float64, bool) {
+	if len(samples) < 2 {
+		return 0, 0, false
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	elapsed := last.time.Sub(first.time).Seconds()
+	if elapsed <= 0 {
+		return 0, 0, false
+	}
+
+	return float64(last.recoveredBytes-first.recoveredBytes) / elapsed,
+		float64(last.recoveredFiles-first.recoveredFiles) / elapsed,
+		true
+}
+
+// recoveryKey identifies a recovery across fetches.
+func recoveryKey(recovery elasticsearch.Recovery) string {
+	return fmt.Sprintf("%s/%d/%t/%s", recovery.Index.Name, recovery.ID, recovery.Primary, recovery.Target.PeerName())
+}
+
+// source returns the node a recovery copies from or the repository and
+// snapshot of a restore.
+func source(recovery elasticsearch.Recovery) string {
+	if peer, ok := recovery.Source.Peer.(elasticsearch.RepositoryPeer); ok {
+		return fmt.Sprintf("%s/%s", peer.Repository, peer.Snapshot)
+	}
+	return recovery.Source.PeerName()
+}
+
+// humanize turns a recovery type or stage (e.g. EXISTING_STORE) into
+// lowercase words.
+func humanize(value string) string {
+	return strings.ToLower(strings.ReplaceAll(value, "_", " "))
 }
 
 func setStyles(theme *styles.Theme) {
@@ -2,7 +2,10 @@ package shardallocationscreen
 
 import (
 	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
 	"esmon/tui/styles"
+	"esmon/tui/tableselection"
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/table"
@@ -10,14 +13,22 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// stateMarker precedes the state of a shard copy. The table does not support
+// styled cells, so the markers are colored after the table has been rendered.
+const stateMarker = "●"
+
 var (
 	defaultTheme = styles.GetTheme(nil)
 
 	shardAllocationTableColumns []table.Column = []table.Column{
-		{Title: "↑Index [*]", Width: 20},
-		{Title: "Shard", Width: 20},
-		{Title: "Primary nodes", Width: 20},
-		{Title: "Replica nodes", Width: 20},
+		{Title: "↑Index [★]", Width: 20},
+		{Title: "Shard", Width: 10},
+		{Title: "Type", Width: 10},
+		{Title: "State", Width: 10},
+		{Title: "Node", Width: 20},
+		{Title: "Docs", Width: 10},
+		{Title: "Size", Width: 10},
+		{Title: "Unassigned reason", Width: 20},
 	}
 
 	shardAllocationTableRows []table.Row
@@ -25,15 +36,26 @@ var (
 	shardAllocationTableStyles = table.DefaultStyles()
 
 	helpStyle = lipgloss.NewStyle().Height(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+
+	stateStyles = map[string]lipgloss.Style{
+		elasticsearch.ShardStateStarted:      lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusGreen),
+		elasticsearch.ShardStateInitializing: lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusYellow),
+		elasticsearch.ShardStateRelocating:   lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorHighlighted),
+		elasticsearch.ShardStateUnassigned:   lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusRed),
+	}
 )
 
-type ShardAllocationMsg []elasticsearch.ShardStores
+type ShardAllocationMsg []elasticsearch.Shard
+type ErrorMsg error
 
 type Model struct {
 	width  int
 	height int
 
 	shardAllocationTable table.Model
+	selection            tableselection.Selection
+
+	errorPanel errorpanel.Model
 }
 
 func New(theme *styles.Theme) Model {
@@ -56,6 +78,8 @@ func New(theme *styles.Theme) Model {
 		Bold(false)
 	m.shardAllocationTable.SetStyles(shardAllocationTableStyles)
 
+	m.errorPanel = errorpanel.New(theme)
+
 	return m
 }
 
@@ -88,44 +112,73 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		m.shardAllocationTable.SetStyles(shardAllocationTableStyles)
 
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
+
 	case ShardAllocationMsg:
-		var shardAllocationTableRows []table.Row
+		m.errorPanel.SetError(nil)
+
+		var (
+			shardAllocationTableRows []table.Row
+			rowKeys                  []string
+		)
+
+		// the unassigned replicas of a shard have no node, they are told
+		// apart by their position among each other
+		copies := make(map[string]int)
 
 		for _, row := range msg {
-			var primaryNodes []string
-			var replicaNodes []string
-
-			for _, store := range row.Stores {
-				if store.Allocation == "primary" {
-					primaryNodes = append(primaryNodes, store.Name)
-				} else {
-					replicaNodes = append(replicaNodes, store.Name)
-				}
+			shardType := "Replica"
+			if row.Primary() {
+				shardType = "Primary"
 			}
 
+			rowKey := fmt.Sprintf("%s/%s/%s/%s", row.Index, row.Shard, row.PriRep, row.Node)
+			rowKeys = append(rowKeys, fmt.Sprintf("%s/%d", rowKey, copies[rowKey]))
+			copies[rowKey]++
 			shardAllocationTableRows = append(shardAllocationTableRows, table.Row{
 				row.Index,
 				row.Shard,
-				strings.Join(primaryNodes, ", "),
-				strings.Join(replicaNodes, ", "),
+				shardType,
+				fmt.Sprintf("%s %s", stateMarker, row.State),
+				row.Node,
+				row.Docs,
+				strings.ToUpper(row.Store),
+				row.UnassignedReason,
 			})
 		}
 
-		m.shardAllocationTable.SetRows(shardAllocationTableRows)
+		m.selection.SetRows(&m.shardAllocationTable, shardAllocationTableRows, rowKeys)
 
 	}
 
 	m.shardAllocationTable, cmd = m.shardAllocationTable.Update(msg)
 	cmds = append(cmds, cmd)
 
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	tableView := m.shardAllocationTable.View()
+	for state, style := range stateStyles {
+		tableView = strings.ReplaceAll(
+			tableView,
+			fmt.Sprintf("%s %s", stateMarker, state),
+			fmt.Sprintf("%s %s", style.Render(stateMarker), state),
+		)
+	}
+
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
-		m.shardAllocationTable.View(),
-		helpStyle.Render("[★] Sorting by index first, shard second"),
+		tableView,
+		helpStyle.Render("[★] Sorting by index first, shard second, primary third"),
 	)
 }
 
@@ -137,4 +190,13 @@ func setStyles(theme *styles.Theme) {
 		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
 
 	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+
+	stateStyles[elasticsearch.ShardStateStarted] = stateStyles[elasticsearch.ShardStateStarted].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusGreen))
+	stateStyles[elasticsearch.ShardStateInitializing] = stateStyles[elasticsearch.ShardStateInitializing].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusYellow))
+	stateStyles[elasticsearch.ShardStateRelocating] = stateStyles[elasticsearch.ShardStateRelocating].
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+	stateStyles[elasticsearch.ShardStateUnassigned] = stateStyles[elasticsearch.ShardStateUnassigned].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
 }
@@ -0,0 +1,645 @@
+package snapshotscreen
+
+import (
+	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
+	"esmon/tui/styles"
+	"esmon/tui/tableselection"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	lipglosstable "github.com/charmbracelet/lipgloss/table"
+)
+
+const (
+	// stateMarker precedes the state of a snapshot. The table does not
+	// support styled cells, so the markers are colored after the table has
+	// been rendered.
+	stateMarker = "●"
+
+	timeFormat = "2006-01-02 15:04"
+)
+
+var (
+	defaultTheme = styles.GetTheme(nil)
+
+	snapshotTableColumns []table.Column = []table.Column{
+		{Title: "↑Repository [★]", Width: 20},
+		{Title: "Type", Width: 10},
+		{Title: "↓Snapshot", Width: 20},
+		{Title: "State", Width: 10},
+		{Title: "Started", Width: 20},
+		{Title: "Ended", Width: 20},
+		{Title: "Failed shards", Width: 10},
+		{Title: "Size", Width: 10},
+		{Title: "Progress", Width: 10},
+	}
+
+	snapshotTableRows []table.Row
+
+	snapshotTableStyles = table.DefaultStyles()
+
+	helpStyle           = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLightMuted)
+	sectionStyle        = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.ForegroundColorLight)
+	sectionHeader       = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+	sectionBorder       = lipgloss.NewStyle().Foreground(defaultTheme.BorderColorMuted)
+	failureStyle        = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.BackgroundColorStatusRed)
+	summaryKeyStyle     = lipgloss.NewStyle().Width(16).Foreground(defaultTheme.ForegroundColorLightMuted)
+	summaryValueStyle   = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLight)
+	helpSeparatorString = " • "
+
+	stateStyles = map[string]lipgloss.Style{
+		elasticsearch.SnapshotStateSuccess:    lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusGreen),
+		elasticsearch.SnapshotStateInProgress: lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorHighlighted),
+		elasticsearch.SnapshotStatePartial:    lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusYellow),
+		elasticsearch.SnapshotStateFailed:     lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusRed),
+	}
+
+	defaultKeyMap = keyMap{
+		details: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("<⏎>", "shards"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("<esc>", "back"),
+		),
+	}
+)
+
+// SnapshotMsg contains the snapshots and the snapshot lifecycle policies,
+// which are empty if the cluster does not support them.
+type SnapshotMsg struct {
+	Snapshots elasticsearch.Snapshots
+	Policies  []elasticsearch.SnapshotPolicy
+}
+type ErrorMsg error
+
+type keyMap struct {
+	details key.Binding
+	back    key.Binding
+}
+
+// snapshotRow is a snapshot of the table. Snapshots in progress which are not
+// listed by their repository yet only have a status.
+type snapshotRow struct {
+	repository elasticsearch.SnapshotRepository
+	snapshot   *elasticsearch.Snapshot
+	status     *elasticsearch.SnapshotStatus
+}
+
+func (r snapshotRow) name() string {
+	switch {
+	case r.snapshot != nil:
+		return r.snapshot.Snapshot
+	case r.status != nil:
+		return r.status.Snapshot
+	}
+	return ""
+}
+
+func (r snapshotRow) key() string {
+	return r.repository.Name + "/" + r.name()
+}
+
+type Model struct {
+	width  int
+	height int
+
+	snapshotTable table.Model
+	selection     tableselection.Selection
+
+	rows     []snapshotRow
+	policies []elasticsearch.SnapshotPolicy
+
+	// the shards of the snapshot with the key replace the snapshot list while
+	// they are shown
+	showDetails     bool
+	detailsKey      string
+	detailsViewport viewport.Model
+
+	help help.Model
+
+	errorPanel errorpanel.Model
+}
+
+func New(theme *styles.Theme) Model {
+	m := Model{}
+
+	m.snapshotTable = table.New(
+		table.WithColumns(snapshotTableColumns),
+		table.WithRows(snapshotTableRows),
+		table.WithFocused(true),
+	)
+
+	snapshotTableStyles.Header = snapshotTableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		BorderBottom(true).
+		Bold(false).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	snapshotTableStyles.Selected = snapshotTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted)).
+		Bold(false)
+	m.snapshotTable.SetStyles(snapshotTableStyles)
+
+	m.detailsViewport = viewport.New(0, 0)
+
+	m.help = help.New()
+	m.help.Styles = styles.HelpStyle
+
+	m.errorPanel = errorpanel.New(theme)
+
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		for index := range snapshotTableColumns {
+			snapshotTableColumns[index].Width = m.width/len(snapshotTableColumns) - 2
+		}
+
+		m.snapshotTable.SetColumns(snapshotTableColumns)
+		m.setTableHeight()
+
+		m.detailsViewport.Width = m.width
+		m.detailsViewport.Height = m.height - 1
+		m.detailsViewport.SetContent(m.renderDetails())
+
+		m.help.Width = m.width - 2
+
+	case styles.ThemeChangeMsg:
+		var theme = styles.Theme(msg)
+		setStyles(&theme)
+
+		m.snapshotTable.SetStyles(snapshotTableStyles)
+		m.help.Styles = styles.HelpStyle
+		m.detailsViewport.SetContent(m.renderDetails())
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, defaultKeyMap.back) && m.showDetails:
+			m.showDetails = false
+			m.detailsKey = ""
+
+		case key.Matches(msg, defaultKeyMap.details) && !m.showDetails:
+			selectedRow := m.snapshotTable.SelectedRow()
+			if selectedRow == nil || selectedRow[2] == "" {
+				break
+			}
+
+			m.showDetails = true
+			m.detailsKey = selectedRow[0] + "/" + selectedRow[2]
+			m.detailsViewport.SetContent(m.renderDetails())
+			m.detailsViewport.GotoTop()
+		}
+
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
+
+	case SnapshotMsg:
+		m.errorPanel.SetError(nil)
+
+		m.rows = snapshotRows(msg.Snapshots)
+		m.policies = msg.Policies
+
+		var (
+			snapshotTableRows []table.Row
+			rowKeys           []string
+		)
+
+		for _, row := range m.rows {
+			rowKeys = append(rowKeys, row.key())
+			snapshotTableRows = append(snapshotTableRows, tableRow(row))
+		}
+
+		m.selection.SetRows(&m.snapshotTable, snapshotTableRows, rowKeys)
+		m.setTableHeight()
+		m.detailsViewport.SetContent(m.renderDetails())
+	}
+
+	if m.showDetails {
+		m.detailsViewport, cmd = m.detailsViewport.Update(msg)
+	} else {
+		m.snapshotTable, cmd = m.snapshotTable.Update(msg)
+	}
+	cmds = append(cmds, cmd)
+
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	if m.showDetails {
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			m.detailsViewport.View(),
+			m.help.ShortHelpView([]key.Binding{defaultKeyMap.back}),
+		)
+	}
+
+	tableView := m.snapshotTable.View()
+	for state, style := range stateStyles {
+		tableView = strings.ReplaceAll(
+			tableView,
+			fmt.Sprintf("%s %s", stateMarker, state),
+			fmt.Sprintf("%s %s", style.Render(stateMarker), state),
+		)
+	}
+
+	views := []string{tableView}
+	if len(m.policies) > 0 {
+		views = append(views, "", m.renderPolicies())
+	}
+	views = append(views,
+		helpStyle.Render("[★] Sorting by repository first, newest snapshot second")+
+			helpStyle.Render(helpSeparatorString)+
+			m.help.ShortHelpView([]key.Binding{defaultKeyMap.details}),
+	)
+
+	return lipgloss.JoinVertical(lipgloss.Top, views...)
+}
+
+// setTableHeight shrinks the snapshot table by the height of the policies
+// shown below it.
+func (m *Model) setTableHeight() {
+	height := m.height - 3
+	if len(m.policies) > 0 {
+		// blank line, header and header border
+		height -= len(m.policies) + 3
+	}
+	m.snapshotTable.SetHeight(max(height, 1))
+}
+
+// snapshotRows returns the snapshots of every repository. Snapshots in
+// progress are listed first with their status, repositories without
+// snapshots get an empty row.
+func snapshotRows(snapshots elasticsearch.Snapshots) []snapshotRow {
+	var rows []snapshotRow
+
+	for _, repository := range snapshots.Repositories {
+		var repositoryRows []snapshotRow
+
+		for index := range snapshots.InProgress {
+			status := &snapshots.InProgress[index]
+			if status.Repository != repository.Name {
+				continue
+			}
+
+			row := snapshotRow{repository: repository, status: status}
+			for snapshotIndex := range repository.Snapshots {
+				if repository.Snapshots[snapshotIndex].Snapshot == status.Snapshot {
+					row.snapshot = &repository.Snapshots[snapshotIndex]
+				}
+			}
+			repositoryRows = append(repositoryRows, row)
+		}
+
+		for index := range repository.Snapshots {
+			snapshot := &repository.Snapshots[index]
+			if snapshot.State == elasticsearch.SnapshotStateInProgress && inProgress(snapshots.InProgress, repository.Name, snapshot.Snapshot) {
+				continue
+			}
+			repositoryRows = append(repositoryRows, snapshotRow{repository: repository, snapshot: snapshot})
+		}
+
+		if len(repositoryRows) == 0 {
+			repositoryRows = append(repositoryRows, snapshotRow{repository: repository})
+		}
+
+		rows = append(rows, repositoryRows...)
+	}
+
+	return rows
+}
+
+func inProgress(statuses []elasticsearch.SnapshotStatus, repository string, snapshot string) bool {
+	for _, status := range statuses {
+		if status.Repository == repository && status.Snapshot == snapshot {
+			return true
+		}
+	}
+	return false
+}
+
+func tableRow(row snapshotRow) table.Row {
+	state, started, ended, failedShards, size, progress := "", "", "", "", "", ""
+
+	if row.snapshot != nil {
+		state = fmt.Sprintf("%s %s", stateMarker, row.snapshot.State)
+		started = formatTime(row.snapshot.StartTimeInMillis)
+		ended = formatTime(row.snapshot.EndTimeInMillis)
+		failedShards = fmt.Sprint(row.snapshot.Shards.Failed)
+		if sizeInBytes := row.snapshot.SizeInBytes(); sizeInBytes > 0 {
+			size = formatBytes(sizeInBytes)
+		}
+	}
+
+	if row.status != nil {
+		state = fmt.Sprintf("%s %s", stateMarker, elasticsearch.SnapshotStateInProgress)
+		started = formatTime(row.status.Stats.StartTimeInMillis)
+		failedShards = fmt.Sprint(row.status.ShardsStats.Failed)
+		size = formatBytes(row.status.Stats.Total.SizeInBytes)
+		progress = fmt.Sprintf(
+			"%.0f%% (%d/%d)",
+			row.status.Stats.Percent(),
+			row.status.ShardsStats.Done,
+			row.status.ShardsStats.Total,
+		)
+	}
+
+	return table.Row{
+		row.repository.Name,
+		row.repository.Type,
+		row.name(),
+		state,
+		started,
+		ended,
+		failedShards,
+		size,
+		progress,
+	}
+}
+
+// renderPolicies renders the last success and failure of every snapshot
+// lifecycle policy. The last failure is highlighted if it is more recent than
+// the last success.
+func (m Model) renderPolicies() string {
+	var rows [][]string
+	for _, policy := range m.policies {
+		rows = append(rows, []string{
+			policy.Name,
+			policy.Policy.Repository,
+			policy.Policy.Schedule,
+			formatInvocation(policy.LastSuccess),
+			formatInvocation(policy.LastFailure),
+			policy.NextExecution,
+		})
+	}
+
+	return m.renderSection(
+		[]string{"Policy", "Repository", "Schedule", "Last success", "Last failure", "Next execution"},
+		rows,
+		func(row int, col int) bool {
+			return col == 4 && m.policies[row].Failed()
+		},
+	)
+}
+
+// renderDetails renders the shards of the snapshot whose details are shown:
+// the progress of every shard of a running snapshot or the shard failures of
+// a finished one.
+func (m Model) renderDetails() string {
+	if !m.showDetails {
+		return ""
+	}
+
+	index := -1
+	for rowIndex, row := range m.rows {
+		if row.key() == m.detailsKey {
+			index = rowIndex
+		}
+	}
+	if index == -1 {
+		return helpStyle.Render("The snapshot is no longer listed")
+	}
+	row := m.rows[index]
+
+	summary := [][]string{
+		{"Snapshot:", row.name()},
+		{"Repository:", fmt.Sprintf("%s (%s)", row.repository.Name, row.repository.Type)},
+	}
+	if row.snapshot != nil && row.status == nil {
+		summary = append(summary,
+			[]string{"State:", row.snapshot.State},
+			[]string{"Shards:", fmt.Sprintf(
+				"%d total, %d successful, %d failed",
+				row.snapshot.Shards.Total,
+				row.snapshot.Shards.Successful,
+				row.snapshot.Shards.Failed,
+			)},
+		)
+	}
+	if row.status != nil {
+		summary = append(summary,
+			[]string{"State:", elasticsearch.SnapshotStateInProgress},
+			[]string{"Shards:", fmt.Sprintf(
+				"%d total, %d done, %d started, %d finalizing, %d initializing, %d failed",
+				row.status.ShardsStats.Total,
+				row.status.ShardsStats.Done,
+				row.status.ShardsStats.Started,
+				row.status.ShardsStats.Finalizing,
+				row.status.ShardsStats.Initializing,
+				row.status.ShardsStats.Failed,
+			)},
+			[]string{"Progress:", fmt.Sprintf(
+				"%.0f%% (%s/%s)",
+				row.status.Stats.Percent(),
+				formatBytes(row.status.Stats.Processed.SizeInBytes),
+				formatBytes(row.status.Stats.Total.SizeInBytes),
+			)},
+		)
+	}
+
+	summaryValueWidth := max(m.width-summaryKeyStyle.GetWidth(), 0)
+
+	var lines []string
+	for _, summaryRow := range summary {
+		lines = append(lines, lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			summaryKeyStyle.Render(summaryRow[0]),
+			summaryValueStyle.Copy().Width(summaryValueWidth).Render(summaryRow[1]),
+		))
+	}
+
+	switch {
+	case row.status != nil:
+		lines = append(lines, "", m.renderShardProgress(row.status))
+	case row.snapshot != nil && len(row.snapshot.Failures) > 0:
+		lines = append(lines, "", m.renderShardFailures(row.snapshot))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderShardProgress renders the stage and the processed size of every shard
+// of a running snapshot, sorted by index and shard.
+func (m Model) renderShardProgress(status *elasticsearch.SnapshotStatus) string {
+	type shardProgress struct {
+		index string
+		shard int
+		stage string
+		stats elasticsearch.SnapshotStats
+	}
+
+	var shards []shardProgress
+	for indexName, index := range status.Indices {
+		for shardId, shard := range index.Shards {
+			number, _ := strconv.Atoi(shardId)
+			shards = append(shards, shardProgress{indexName, number, shard.Stage, shard.Stats})
+		}
+	}
+
+	sort.Slice(shards, func(i, j int) bool {
+		if shards[i].index != shards[j].index {
+			return shards[i].index < shards[j].index
+		}
+		return shards[i].shard < shards[j].shard
+	})
+
+	var rows [][]string
+	for _, shard := range shards {
+		rows = append(rows, []string{
+			shard.index,
+			fmt.Sprint(shard.shard),
+			strings.ToLower(shard.stage),
+			formatBytes(shard.stats.Processed.SizeInBytes),
+			formatBytes(shard.stats.Total.SizeInBytes),
+			fmt.Sprintf("%.0f%%", shard.stats.Percent()),
+		})
+	}
+
+	return m.renderSection(
+		[]string{"Index", "Shard", "Stage", "Processed", "Total", "Progress"},
+		rows,
+		func(row int, col int) bool {
+			return col == 2 && shards[row].stage == "FAILURE"
+		},
+	)
+}
+
+// renderShardFailures renders the shards a finished snapshot failed to store.
+func (m Model) renderShardFailures(snapshot *elasticsearch.Snapshot) string {
+	var rows [][]string
+	for _, failure := range snapshot.Failures {
+		rows = append(rows, []string{
+			failure.Index,
+			fmt.Sprint(failure.ShardId),
+			failure.NodeId,
+			failure.Reason,
+		})
+	}
+
+	return m.renderSection(
+		[]string{"Index", "Shard", "Node", "Reason"},
+		rows,
+		func(row int, col int) bool {
+			return col == 3
+		},
+	)
+}
+
+// renderSection renders a table below the snapshot list or in the details.
+// Cells for which failed returns true are highlighted.
+func (m Model) renderSection(headers []string, rows [][]string, failed func(row int, col int) bool) string {
+	width := max(m.width/len(headers), 1)
+
+	return lipglosstable.New().
+		Headers(headers...).
+		Rows(rows...).
+		BorderTop(false).
+		BorderRight(false).
+		BorderBottom(false).
+		BorderLeft(false).
+		BorderColumn(false).
+		BorderHeader(true).
+		BorderStyle(sectionBorder).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := sectionStyle
+			switch {
+			case row == 0:
+				style = sectionHeader
+			case failed(row-1, col):
+				style = failureStyle
+			}
+			return style.Copy().Width(width).MaxHeight(1)
+		}).
+		Render()
+}
+
+// formatInvocation returns the time and snapshot of a policy run, or an
+// empty string if the policy has not run yet.
+func formatInvocation(invocation elasticsearch.SnapshotInvocation) string {
+	if invocation.Time == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", formatTime(invocation.Time), invocation.SnapshotName)
+}
+
+// formatTime formats epoch milliseconds in local time, zero is not set.
+func formatTime(millis int64) string {
+	if millis == 0 {
+		return ""
+	}
+	return time.UnixMilli(millis).Format(timeFormat)
+}
+
+// formatBytes formats a size with the largest binary unit below it like the
+// sizes reported by Elasticsearch (e.g. 1.2GB).
+func formatBytes(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+	size := float64(bytes)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d%s", bytes, units[unit])
+	}
+	return fmt.Sprintf("%.1f%s", size, units[unit])
+}
+
+func setStyles(theme *styles.Theme) {
+	snapshotTableStyles.Header = snapshotTableStyles.Header.
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	snapshotTableStyles.Selected = snapshotTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	sectionStyle = sectionStyle.Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	sectionHeader = sectionHeader.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	sectionBorder = sectionBorder.Foreground(lipgloss.Color(theme.BorderColorMuted))
+	failureStyle = failureStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+	summaryKeyStyle = summaryKeyStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	summaryValueStyle = summaryValueStyle.Foreground(lipgloss.Color(theme.ForegroundColorLight))
+
+	stateStyles[elasticsearch.SnapshotStateSuccess] = stateStyles[elasticsearch.SnapshotStateSuccess].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusGreen))
+	stateStyles[elasticsearch.SnapshotStateInProgress] = stateStyles[elasticsearch.SnapshotStateInProgress].
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+	stateStyles[elasticsearch.SnapshotStatePartial] = stateStyles[elasticsearch.SnapshotStatePartial].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusYellow))
+	stateStyles[elasticsearch.SnapshotStateFailed] = stateStyles[elasticsearch.SnapshotStateFailed].
+		Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+}
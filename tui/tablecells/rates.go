@@ -0,0 +1,22 @@
+package tablecells
+
+import (
+	"esmon/elasticsearch"
+	"fmt"
+)
+
+// OperationRates returns the cells of the Index/s, Search/s, Get/s, Refresh/s
+// and Merge/s columns, which are empty if the rates are unknown.
+func OperationRates(rates elasticsearch.OperationRates, ok bool) []string {
+	if !ok {
+		return []string{"", "", "", "", ""}
+	}
+
+	return []string{
+		fmt.Sprintf("%.1f", rates.Indexing),
+		fmt.Sprintf("%.1f", rates.Search),
+		fmt.Sprintf("%.1f", rates.Get),
+		fmt.Sprintf("%.1f", rates.Refresh),
+		fmt.Sprintf("%.1f", rates.Merge),
+	}
+}
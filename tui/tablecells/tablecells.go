@@ -0,0 +1,85 @@
+package tablecells
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// StyleFunc returns the style of the cell in the row and column of the table,
+// ok is false for cells which are rendered by the table as usual.
+type StyleFunc func(row, column int) (style lipgloss.Style, ok bool)
+
+// View renders the table with the cells styled by styleFunc. The table does not
+// support styled cells, it truncates their escape sequences like text. So the
+// rows with styled cells are rendered like the table renders them, once as it
+// does and once with the styled cells, and the lines of the rendered table
+// which start with the former are replaced by the latter. Cells keep the style
+// of the selected row unless they are styled. columns and styles are the ones
+// set on the table, which does not return them.
+func View(t table.Model, columns []table.Column, styles table.Styles, styleFunc StyleFunc) string {
+	view := t.View()
+
+	var plainRows, styledRows []string
+	for index, row := range t.Rows() {
+		styled := false
+		for column := range row {
+			if _, ok := styleFunc(index, column); ok {
+				styled = true
+				break
+			}
+		}
+		if !styled {
+			continue
+		}
+
+		selected := index == t.Cursor()
+		plainRows = append(plainRows, renderRow(row, columns, styles, selected, nil))
+		styledRows = append(styledRows, renderRow(row, columns, styles, selected, func(column int) (lipgloss.Style, bool) {
+			return styleFunc(index, column)
+		}))
+	}
+	if len(plainRows) == 0 {
+		return view
+	}
+
+	lines := strings.Split(view, "\n")
+	for lineIndex, line := range lines {
+		for index, plainRow := range plainRows {
+			if rest, ok := strings.CutPrefix(line, plainRow); ok {
+				lines[lineIndex] = styledRows[index] + rest
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderRow renders the row like the table. Without cellStyle, the selected
+// row is styled as a whole like the table does, otherwise each cell is.
+func renderRow(row table.Row, columns []table.Column, styles table.Styles, selected bool, cellStyle func(column int) (lipgloss.Style, bool)) string {
+	cells := make([]string, 0, len(columns))
+	for column, value := range row {
+		width := columns[column].Width
+		cell := lipgloss.NewStyle().Width(width).MaxWidth(width).Inline(true).Render(runewidth.Truncate(value, width, "…"))
+
+		if cellStyle != nil {
+			if style, ok := cellStyle(column); ok {
+				cell = style.Render(cell)
+			} else if selected {
+				cell = styles.Selected.Render(cell)
+			}
+		}
+
+		cells = append(cells, styles.Cell.Render(cell))
+	}
+
+	line := lipgloss.JoinHorizontal(lipgloss.Left, cells...)
+	if cellStyle == nil && selected {
+		return styles.Selected.Render(line)
+	}
+	return line
+}
@@ -0,0 +1,41 @@
+package tablecolumns
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// cellPadding is the horizontal padding of a table cell, one character on
+// both sides.
+const cellPadding = 2
+
+// Fit returns a copy of the columns with widths filling the table width. The
+// columns with a width in fixedWidths (by title) get it first, so their
+// content is not truncated. The other columns share the remaining width in
+// proportion to their width, which is their preferred width.
+func Fit(columns []table.Column, width int, fixedWidths map[string]int) []table.Column {
+	fitted := slices.Clone(columns)
+
+	remainingWidth := width - cellPadding*len(fitted)
+	preferredWidth := 0
+	for _, column := range fitted {
+		if fixedWidth, ok := fixedWidths[column.Title]; ok {
+			remainingWidth -= fixedWidth
+		} else {
+			preferredWidth += column.Width
+		}
+	}
+
+	for index, column := range fitted {
+		if fixedWidth, ok := fixedWidths[column.Title]; ok {
+			fitted[index].Width = fixedWidth
+			continue
+		}
+
+		// a width of zero is not applied to the cells at all
+		fitted[index].Width = max(column.Width*remainingWidth/max(preferredWidth, 1), 1)
+	}
+
+	return fitted
+}
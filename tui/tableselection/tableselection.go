@@ -0,0 +1,30 @@
+package tableselection
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// Selection keeps the selected row of a table selected while its rows are
+// replaced, e.g. by a refresh which reordered them. Rows are identified by a
+// key (e.g. the node ID) as their cells change between refreshes.
+type Selection struct {
+	keys []string
+}
+
+// SetRows replaces the rows of the table, keys contains the key of each row.
+// The row with the key of the selected row is selected afterwards. If the row
+// is gone, the cursor keeps its position.
+func (s *Selection) SetRows(t *table.Model, rows []table.Row, keys []string) {
+	cursor := t.Cursor()
+	if cursor >= 0 && cursor < len(s.keys) {
+		if index := slices.Index(keys, s.keys[cursor]); index != -1 {
+			cursor = index
+		}
+	}
+
+	s.keys = keys
+	t.SetRows(rows)
+	t.SetCursor(cursor)
+}
@@ -0,0 +1,217 @@
+package threadpoolscreen
+
+import (
+	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
+	"esmon/tui/styles"
+	"esmon/tui/tablecells"
+	"esmon/tui/tableselection"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// newRejectionsColumn is the index of the column with the tasks rejected since
+// the previous refresh
+const newRejectionsColumn = 6
+
+var (
+	defaultTheme = styles.GetTheme(nil)
+
+	threadPoolTableColumns []table.Column = []table.Column{
+		{Title: "↑Node [★]", Width: 20},
+		{Title: "Pool", Width: 20},
+		{Title: "Active", Width: 10},
+		{Title: "Queue", Width: 10},
+		{Title: "Queue size", Width: 10},
+		{Title: "Rejected", Width: 10},
+		{Title: "New rejections", Width: 10},
+		{Title: "Completed", Width: 10},
+	}
+
+	threadPoolTableRows []table.Row
+
+	threadPoolTableStyles = table.DefaultStyles()
+
+	helpStyle = lipgloss.NewStyle().Height(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+
+	rejectionStyle = lipgloss.NewStyle().Foreground(defaultTheme.BackgroundColorStatusRed)
+)
+
+type ThreadPoolMsg []elasticsearch.ThreadPool
+type ErrorMsg error
+
+// ActivatedMsg is sent when the screen is shown. The screen only receives
+// data while it is shown, so the new rejections are counted from the next
+// refresh on instead of covering the time the screen was hidden.
+type ActivatedMsg struct{}
+
+type Model struct {
+	width  int
+	height int
+
+	threadPoolTable table.Model
+	selection       tableselection.Selection
+
+	// rejected contains the rejected counts of the previous refresh by node
+	// ID and pool name
+	rejected map[string]int64
+	// rejecting reports for each row whether the thread pool rejected tasks
+	// since the previous refresh
+	rejecting []bool
+
+	errorPanel errorpanel.Model
+}
+
+func New(theme *styles.Theme) Model {
+	m := Model{}
+
+	m.threadPoolTable = table.New(
+		table.WithColumns(threadPoolTableColumns),
+		table.WithRows(threadPoolTableRows),
+		table.WithFocused(true),
+	)
+
+	threadPoolTableStyles.Header = threadPoolTableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		BorderBottom(true).
+		Bold(false).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	threadPoolTableStyles.Selected = threadPoolTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted)).
+		Bold(false)
+	m.threadPoolTable.SetStyles(threadPoolTableStyles)
+
+	m.errorPanel = errorpanel.New(theme)
+
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		for index := range threadPoolTableColumns {
+			threadPoolTableColumns[index].Width = m.width/len(threadPoolTableColumns) - 2
+		}
+
+		m.threadPoolTable.SetHeight(m.height - 3)
+		m.threadPoolTable.SetColumns(threadPoolTableColumns)
+
+		helpStyle.Width(m.width - 2)
+
+	case styles.ThemeChangeMsg:
+		var theme = styles.Theme(msg)
+		setStyles(&theme)
+
+		m.threadPoolTable.SetStyles(threadPoolTableStyles)
+
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
+
+	case ActivatedMsg:
+		m.rejected = nil
+
+	case ThreadPoolMsg:
+		m.errorPanel.SetError(nil)
+
+		var (
+			threadPoolTableRows []table.Row
+			rowKeys             []string
+			rejecting           []bool
+		)
+		rejected := make(map[string]int64, len(msg))
+
+		for _, row := range msg {
+			key := row.NodeId + "/" + row.Name
+			rejected[key] = row.RejectedCount()
+
+			newRejections := ""
+			var delta int64
+			if previous, ok := m.rejected[key]; ok {
+				delta = row.RejectedCount() - previous
+				if delta < 0 {
+					// the counter restarts with the node
+					delta = row.RejectedCount()
+				}
+				newRejections = fmt.Sprintf("%d", delta)
+			}
+
+			rowKeys = append(rowKeys, key)
+			rejecting = append(rejecting, delta > 0)
+			threadPoolTableRows = append(threadPoolTableRows, table.Row{
+				row.NodeName,
+				row.Name,
+				row.Active,
+				row.Queue,
+				row.QueueSize,
+				row.Rejected,
+				newRejections,
+				row.Completed,
+			})
+		}
+
+		m.rejected = rejected
+		m.rejecting = rejecting
+		m.selection.SetRows(&m.threadPoolTable, threadPoolTableRows, rowKeys)
+
+	}
+
+	m.threadPoolTable, cmd = m.threadPoolTable.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	// rows of thread pools which rejected tasks are highlighted, except for
+	// the selected one, which only highlights its new rejections so that it
+	// still looks selected
+	tableView := tablecells.View(m.threadPoolTable, threadPoolTableColumns, threadPoolTableStyles, func(row, column int) (lipgloss.Style, bool) {
+		if row >= len(m.rejecting) || !m.rejecting[row] {
+			return lipgloss.Style{}, false
+		}
+		if row == m.threadPoolTable.Cursor() && column != newRejectionsColumn {
+			return lipgloss.Style{}, false
+		}
+		return rejectionStyle, true
+	})
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		tableView,
+		helpStyle.Render("[★] Sorting by node first, pool second. Highlighted thread pools rejected tasks since the previous refresh"),
+	)
+}
+
+func setStyles(theme *styles.Theme) {
+	threadPoolTableStyles.Header = threadPoolTableStyles.Header.
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	threadPoolTableStyles.Selected = threadPoolTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+
+	rejectionStyle = rejectionStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+}
@@ -8,13 +8,20 @@ import (
 	"esmon/constants"
 	"esmon/elasticsearch"
 	"esmon/tui/clusterscreen"
+	"esmon/tui/datastreamscreen"
+	"esmon/tui/errorpanel"
 	"esmon/tui/indexscreen"
 	"esmon/tui/loadingscreen"
 	"esmon/tui/nodescreen"
+	"esmon/tui/pendingtasksscreen"
 	"esmon/tui/relocatingshardsscreen"
 	"esmon/tui/shardallocationscreen"
+	"esmon/tui/snapshotscreen"
 	"esmon/tui/styles"
+	"esmon/tui/threadpoolscreen"
+	"esmon/tui/unassignedshardsscreen"
 	"fmt"
+	"net/url"
 	"slices"
 	"strings"
 	"time"
@@ -59,6 +66,8 @@ var (
 	kvTableKeyStyle   = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.ForegroundColorLight)
 	kvTableValueStyle = lipgloss.NewStyle().PaddingLeft(1).Foreground(defaultTheme.ForegroundColorLight)
 
+	keyValueColumnSeparator = "    "
+
 	defaultKeyMap = keyMap{
 		shardAllocation: key.NewBinding(
 			key.WithKeys("s"),
@@ -76,6 +85,26 @@ var (
 			key.WithKeys("i"),
 			key.WithHelp("<i>", "Index overview"),
 		),
+		unassignedShards: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("<U>", "Unassigned shards"),
+		),
+		pendingTasks: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("<p>", "Pending tasks"),
+		),
+		threadPools: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("<t>", "Thread pools"),
+		),
+		snapshots: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("<S>", "Snapshots"),
+		),
+		dataStreams: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("<D>", "Data streams"),
+		),
 		clusters: key.NewBinding(
 			key.WithKeys("c"),
 			key.WithHelp("<c>", "Clusters"),
@@ -103,10 +132,51 @@ var (
 		&defaultKeyMap.relocatingShards,
 		&defaultKeyMap.nodeOverview,
 		&defaultKeyMap.indexOverview,
+		&defaultKeyMap.unassignedShards,
+		&defaultKeyMap.pendingTasks,
+		&defaultKeyMap.threadPools,
+		&defaultKeyMap.snapshots,
+		&defaultKeyMap.dataStreams,
 		&defaultKeyMap.clusters,
 		&defaultKeyMap.compactMode,
 	}
 
+	// the data shown in the header is fetched on every refresh, the node
+	// stats are compared to the disk watermarks, the latest snapshot of each
+	// repository and the snapshot lifecycle policies report failed snapshots
+	headerDataSources = []elasticsearch.DataSource{
+		elasticsearch.ClusterHealthSource,
+		elasticsearch.ClusterStatsSource,
+		elasticsearch.NodeStatsSource,
+		elasticsearch.ClusterSettingsSource,
+		elasticsearch.LatestSnapshotSource,
+		elasticsearch.SnapshotPoliciesSource,
+	}
+
+	// screenDataSources contains the data sources displayed by each screen.
+	// They are only fetched while the screen is active.
+	screenDataSources = map[screen][]elasticsearch.DataSource{
+		shardAllocation:  {elasticsearch.ShardsSource},
+		relocatingShards: {elasticsearch.RecoveriesSource},
+		nodeOverview:     {elasticsearch.NodeStatsSource, elasticsearch.MasterNodeSource},
+		indexOverview:    {elasticsearch.IndexStatsSource, elasticsearch.IndexLifecycleSource},
+		unassignedShards: {elasticsearch.ShardsSource},
+		pendingTasks:     {elasticsearch.PendingTasksSource},
+		threadPools:      {elasticsearch.ThreadPoolsSource},
+		snapshots:        {elasticsearch.SnapshotsSource, elasticsearch.SnapshotPoliciesSource},
+		dataStreams:      {elasticsearch.DataStreamsSource, elasticsearch.IndexStatsSource, elasticsearch.IndexLifecycleSource},
+	}
+
+	// dataSourceMinimumAges delays the refresh of data sources which are
+	// expensive for the cluster to compute
+	dataSourceMinimumAges = map[elasticsearch.DataSource]time.Duration{
+		elasticsearch.ShardsSource:         10 * time.Second,
+		elasticsearch.RecoveriesSource:     10 * time.Second,
+		elasticsearch.IndexLifecycleSource: 30 * time.Second,
+		elasticsearch.SnapshotsSource:      time.Minute,
+		elasticsearch.LatestSnapshotSource: time.Minute,
+	}
+
 	refreshContextCancelFunc     context.CancelFunc
 	refreshTickContextCancelFunc context.CancelFunc
 )
@@ -118,6 +188,11 @@ type keyMap struct {
 	relocatingShards          key.Binding
 	nodeOverview              key.Binding
 	indexOverview             key.Binding
+	unassignedShards          key.Binding
+	pendingTasks              key.Binding
+	threadPools               key.Binding
+	snapshots                 key.Binding
+	dataStreams               key.Binding
 	clusters                  key.Binding
 	compactMode               key.Binding
 	refresh                   key.Binding
@@ -133,11 +208,22 @@ const (
 	relocatingShards
 	nodeOverview
 	indexOverview
+	unassignedShards
+	pendingTasks
+	threadPools
+	snapshots
+	dataStreams
 	clusters
 )
 
 type refreshingMsg bool
-type refreshErrorMsg error
+
+// refreshErrorMsg and clusterDataMsg are the results of a refresh by the
+// client. Results of the client of a previous cluster are dropped.
+type refreshErrorMsg struct {
+	client *elasticsearch.Client
+	err    error
+}
 
 type autorefreshIntervalChangeMsg uint
 type autorefreshTickMsg time.Time
@@ -146,10 +232,16 @@ type initMsg struct {
 	args           arguments.Args
 	config         config.Config
 	currentCluster *config.ClusterConfig
+	client         *elasticsearch.Client
 	clusterData    *elasticsearch.ClusterData
+	refreshContext context.Context
+	err            error
 }
 
-type clusterDataMsg *elasticsearch.ClusterData
+type clusterDataMsg struct {
+	client      *elasticsearch.Client
+	clusterData *elasticsearch.ClusterData
+}
 
 type mainModel struct {
 	width  int
@@ -162,15 +254,26 @@ type mainModel struct {
 	relocatingShardsScreen relocatingshardsscreen.Model
 	nodeScreen             nodescreen.Model
 	indexScreen            indexscreen.Model
+	unassignedShardsScreen unassignedshardsscreen.Model
+	pendingTasksScreen     pendingtasksscreen.Model
+	threadPoolScreen       threadpoolscreen.Model
+	snapshotScreen         snapshotscreen.Model
+	dataStreamScreen       datastreamscreen.Model
 	clusterScreen          clusterscreen.Model
+	errorPanel             errorpanel.Model
 
 	screen      screen
 	compactMode bool
 
 	clusterConfig  []config.ClusterConfig
 	currentCluster *config.ClusterConfig
+	client         *elasticsearch.Client
 	clusterData    *elasticsearch.ClusterData
 
+	// the refreshes of the client are canceled by refreshContextCancelFunc
+	// when the client is closed
+	refreshContext context.Context
+
 	defaultCredentials elasticsearch.Credentials
 
 	refreshing   bool
@@ -181,7 +284,8 @@ type mainModel struct {
 
 	refreshSpinner spinner.Model
 
-	httpConfig config.HttpConfig
+	httpConfig       config.HttpConfig
+	insecureOverride *bool
 
 	err error
 }
@@ -194,7 +298,13 @@ func NewMainModel() mainModel {
 	m.relocatingShardsScreen = relocatingshardsscreen.New(&defaultTheme)
 	m.nodeScreen = nodescreen.New(&defaultTheme)
 	m.indexScreen = indexscreen.New(&defaultTheme)
+	m.unassignedShardsScreen = unassignedshardsscreen.New(&defaultTheme)
+	m.pendingTasksScreen = pendingtasksscreen.New(&defaultTheme)
+	m.threadPoolScreen = threadpoolscreen.New(&defaultTheme)
+	m.snapshotScreen = snapshotscreen.New(&defaultTheme)
+	m.dataStreamScreen = datastreamscreen.New(&defaultTheme)
 	m.clusterScreen = clusterscreen.New(&defaultTheme)
+	m.errorPanel = errorpanel.New(&defaultTheme)
 
 	m.screen = loading
 	m.compactMode = false
@@ -219,6 +329,11 @@ func (m mainModel) Init() tea.Cmd {
 	cmds = append(cmds, m.relocatingShardsScreen.Init())
 	cmds = append(cmds, m.nodeScreen.Init())
 	cmds = append(cmds, m.indexScreen.Init())
+	cmds = append(cmds, m.unassignedShardsScreen.Init())
+	cmds = append(cmds, m.pendingTasksScreen.Init())
+	cmds = append(cmds, m.threadPoolScreen.Init())
+	cmds = append(cmds, m.snapshotScreen.Init())
+	cmds = append(cmds, m.dataStreamScreen.Init())
 	cmds = append(cmds, m.clusterScreen.Init())
 	cmds = append(cmds, m.refreshSpinner.Tick)
 
@@ -277,12 +392,44 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		})
 		cmds = append(cmds, cmd)
 
+		m.unassignedShardsScreen, cmd = m.unassignedShardsScreen.Update(tea.WindowSizeMsg{
+			Width: m.width - 2, Height: m.height - styles.OverviewHeight - 5,
+		})
+		cmds = append(cmds, cmd)
+
+		m.pendingTasksScreen, cmd = m.pendingTasksScreen.Update(tea.WindowSizeMsg{
+			Width: m.width - 2, Height: m.height - styles.OverviewHeight - 5,
+		})
+		cmds = append(cmds, cmd)
+
+		m.threadPoolScreen, cmd = m.threadPoolScreen.Update(tea.WindowSizeMsg{
+			Width: m.width - 2, Height: m.height - styles.OverviewHeight - 5,
+		})
+		cmds = append(cmds, cmd)
+
+		m.snapshotScreen, cmd = m.snapshotScreen.Update(tea.WindowSizeMsg{
+			Width: m.width - 2, Height: m.height - styles.OverviewHeight - 5,
+		})
+		cmds = append(cmds, cmd)
+
+		m.dataStreamScreen, cmd = m.dataStreamScreen.Update(tea.WindowSizeMsg{
+			Width: m.width - 2, Height: m.height - styles.OverviewHeight - 5,
+		})
+		cmds = append(cmds, cmd)
+
 		m.clusterScreen, cmd = m.clusterScreen.Update(tea.WindowSizeMsg{
 			Width: m.width - 2, Height: m.height - styles.OverviewHeight - 5,
 		})
 		cmds = append(cmds, cmd)
 
+		m.errorPanel, cmd = m.errorPanel.Update(tea.WindowSizeMsg{
+			Width: m.width - 2, Height: m.height - styles.OverviewHeight - 5,
+		})
+		cmds = append(cmds, cmd)
+
 	case tea.KeyMsg:
+		previousScreen, previousCompactMode := m.screen, m.compactMode
+
 		switch {
 		case key.Matches(msg, defaultKeyMap.shardAllocation) && !m.compactMode:
 			m.screen = shardAllocation
@@ -292,21 +439,24 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.screen = nodeOverview
 		case key.Matches(msg, defaultKeyMap.indexOverview) && !m.compactMode:
 			m.screen = indexOverview
+		case key.Matches(msg, defaultKeyMap.unassignedShards) && !m.compactMode:
+			m.screen = unassignedShards
+		case key.Matches(msg, defaultKeyMap.pendingTasks) && !m.compactMode:
+			m.screen = pendingTasks
+		case key.Matches(msg, defaultKeyMap.threadPools) && !m.compactMode:
+			m.screen = threadPools
+		case key.Matches(msg, defaultKeyMap.snapshots) && !m.compactMode:
+			m.screen = snapshots
+		case key.Matches(msg, defaultKeyMap.dataStreams) && !m.compactMode:
+			m.screen = dataStreams
 		case key.Matches(msg, defaultKeyMap.clusters) && !m.compactMode:
 			m.screen = clusters
 		case key.Matches(msg, defaultKeyMap.compactMode):
 			m.compactMode = !m.compactMode
 		case key.Matches(msg, defaultKeyMap.refresh):
-			if m.currentCluster != nil && m.refreshIntervalSeconds == 0 && !m.refreshing {
+			if m.client != nil && m.refreshIntervalSeconds == 0 && !m.refreshing {
 				m.refreshing = true
-				cmds = append(
-					cmds,
-					refreshData(
-						m.currentCluster,
-						&m.defaultCredentials,
-						m.httpConfig,
-					),
-				)
+				cmds = append(cmds, refreshData(m.refreshContext, m.client, m.dataSources(activeDataSources(m.screen, m.compactMode))))
 			}
 		case key.Matches(msg, defaultKeyMap.changeAutorefreshInterval):
 			cmds = append(cmds, changeAutorefreshInterval(m.refreshIntervalSeconds))
@@ -317,6 +467,9 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if refreshTickContextCancelFunc != nil {
 				refreshTickContextCancelFunc()
 			}
+			if m.client != nil {
+				m.client.Close()
+			}
 			cmds = append(cmds, tea.Quit)
 		default:
 			switch m.screen {
@@ -332,15 +485,57 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case indexOverview:
 				m.indexScreen, cmd = m.indexScreen.Update(msg)
 				cmds = append(cmds, cmd)
+			case unassignedShards:
+				m.unassignedShardsScreen, cmd = m.unassignedShardsScreen.Update(msg)
+				cmds = append(cmds, cmd)
+			case pendingTasks:
+				m.pendingTasksScreen, cmd = m.pendingTasksScreen.Update(msg)
+				cmds = append(cmds, cmd)
+			case threadPools:
+				m.threadPoolScreen, cmd = m.threadPoolScreen.Update(msg)
+				cmds = append(cmds, cmd)
+			case snapshots:
+				m.snapshotScreen, cmd = m.snapshotScreen.Update(msg)
+				cmds = append(cmds, cmd)
+			case dataStreams:
+				m.dataStreamScreen, cmd = m.dataStreamScreen.Update(msg)
+				cmds = append(cmds, cmd)
 			case clusters:
 				m.clusterScreen, cmd = m.clusterScreen.Update(msg)
 				cmds = append(cmds, cmd)
 			}
 		}
 
+		// screens showing changes since the previous refresh start over when
+		// they are shown
+		if !m.compactMode && (m.screen != previousScreen || previousCompactMode) {
+			switch m.screen {
+			case nodeOverview:
+				m.nodeScreen, cmd = m.nodeScreen.Update(nodescreen.ActivatedMsg{})
+				cmds = append(cmds, cmd)
+			case threadPools:
+				m.threadPoolScreen, cmd = m.threadPoolScreen.Update(threadpoolscreen.ActivatedMsg{})
+				cmds = append(cmds, cmd)
+			}
+		}
+
+		// the data of a screen which was inactive during the latest refresh
+		// is fetched as soon as the screen is shown
+		if (m.screen != previousScreen || m.compactMode != previousCompactMode) && m.client != nil && !m.refreshing {
+			if sources := m.staleDataSources(); len(sources) > 0 {
+				m.refreshing = true
+				cmds = append(cmds, refreshData(m.refreshContext, m.client, sources))
+			}
+		}
+
 	case refreshErrorMsg:
+		if msg.client != m.client {
+			break
+		}
+
 		m.refreshing = false
 		m.refreshError = true
+		m.errorPanel.SetError(msg.err)
 
 	case autorefreshIntervalChangeMsg:
 		if refreshTickContextCancelFunc != nil {
@@ -361,16 +556,12 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case autorefreshTickMsg:
-		if m.refreshIntervalSeconds > 0 {
+		if m.client != nil && m.refreshIntervalSeconds > 0 {
 			m.refreshing = true
 			cmds = append(
 				cmds,
 				tea.Sequence(
-					refreshData(
-						m.currentCluster,
-						&m.defaultCredentials,
-						m.httpConfig,
-					),
+					refreshData(m.refreshContext, m.client, m.dataSources(activeDataSources(m.screen, m.compactMode))),
 					autorefreshTick(m.refreshIntervalSeconds),
 				),
 			)
@@ -395,54 +586,51 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.indexScreen, cmd = m.indexScreen.Update(styles.ThemeChangeMsg(m.theme))
 		cmds = append(cmds, cmd)
 
+		m.unassignedShardsScreen, cmd = m.unassignedShardsScreen.Update(styles.ThemeChangeMsg(m.theme))
+		cmds = append(cmds, cmd)
+
+		m.pendingTasksScreen, cmd = m.pendingTasksScreen.Update(styles.ThemeChangeMsg(m.theme))
+		cmds = append(cmds, cmd)
+
+		m.threadPoolScreen, cmd = m.threadPoolScreen.Update(styles.ThemeChangeMsg(m.theme))
+		cmds = append(cmds, cmd)
+
+		m.snapshotScreen, cmd = m.snapshotScreen.Update(styles.ThemeChangeMsg(m.theme))
+		cmds = append(cmds, cmd)
+
+		m.dataStreamScreen, cmd = m.dataStreamScreen.Update(styles.ThemeChangeMsg(m.theme))
+		cmds = append(cmds, cmd)
+
 		m.clusterScreen, cmd = m.clusterScreen.Update(styles.ThemeChangeMsg(m.theme))
 		cmds = append(cmds, cmd)
 
+		m.errorPanel, cmd = m.errorPanel.Update(styles.ThemeChangeMsg(m.theme))
+		cmds = append(cmds, cmd)
+
+		m.errorPanel.SetError(msg.err)
+
 		m.clusterConfig = msg.config.Clusters
 		m.currentCluster = msg.currentCluster
+		m.client = msg.client
 		m.clusterData = msg.clusterData
+		m.refreshContext = msg.refreshContext
 
 		m.defaultCredentials = elasticsearch.Credentials{
-			Username: msg.args.Username,
-			Password: msg.args.Password,
+			Username:    msg.args.Username,
+			Password:    msg.args.Password,
+			ApiKey:      msg.args.ApiKey,
+			BearerToken: msg.args.BearerToken,
 		}
 
 		m.refreshIntervalSeconds = msg.config.General.RefreshInterval
 
-		httpInsecure := msg.config.Http.Insecure
-		if msg.args.Insecure != nil {
-			httpInsecure = *msg.args.Insecure
-		}
-
-		m.httpConfig = config.HttpConfig{
-			Timeout:  msg.config.Http.Timeout,
-			Insecure: httpInsecure,
-		}
+		m.httpConfig = msg.config.Http
+		m.insecureOverride = msg.args.Insecure
 
 		if m.clusterData != nil {
 			m.lastRefresh = time.Now()
 
-			m.shardAllocationScreen, cmd = m.shardAllocationScreen.Update(
-				shardallocationscreen.ShardAllocationMsg(m.clusterData.ShardStores),
-			)
-			cmds = append(cmds, cmd)
-
-			m.relocatingShardsScreen, cmd = m.relocatingShardsScreen.Update(
-				relocatingshardsscreen.ShardMsg(m.clusterData.Recoveries),
-			)
-			cmds = append(cmds, cmd)
-
-			m.nodeScreen, cmd = m.nodeScreen.Update(
-				nodescreen.NodeMsg{
-					Nodes:      m.clusterData.NodeStats,
-					MasterNode: m.clusterData.MasterNode,
-				},
-			)
-			cmds = append(cmds, cmd)
-
-			m.indexScreen, cmd = m.indexScreen.Update(
-				indexscreen.IndexMsg(m.clusterData.IndexStats),
-			)
+			m, cmd = m.updateScreens(m.clusterData)
 			cmds = append(cmds, cmd)
 		} else {
 			m.refreshError = true
@@ -482,30 +670,68 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		index := slices.IndexFunc(
 			m.clusterConfig,
 			func(c config.ClusterConfig) bool {
-				return c.Endpoint == string(msg)
+				return c.Alias == string(msg)
 			})
 
 		m.currentCluster = &m.clusterConfig[index]
 		m.clusterData = nil
 
+		m, cmd = m.resetScreens()
+		cmds = append(cmds, cmd)
+
+		if m.client != nil {
+			m.client.Close()
+		}
+
+		client, err := newClient(m.currentCluster, &m.defaultCredentials, m.httpConfig, m.insecureOverride)
+		if err != nil {
+			m.client = nil
+			// the results of a refresh of the previous client are dropped
+			m.refreshing = false
+			m.refreshError = true
+			m.errorPanel.SetError(err)
+			break
+		}
+		m.client = client
+		m.errorPanel.SetError(nil)
+
+		m.refreshContext, refreshContextCancelFunc = context.WithCancel(context.Background())
+
 		m.refreshing = true
 		m.lastRefresh = time.Time{}
 
-		cmds = append(
-			cmds,
-			refreshData(
-				m.currentCluster,
-				&m.defaultCredentials,
-				m.httpConfig,
-			),
-		)
+		cmds = append(cmds, refreshData(m.refreshContext, m.client, activeDataSources(m.screen, m.compactMode)))
 
 	case clusterDataMsg:
+		// a refresh which was canceled by a cluster switch may still return
+		// the data of the sources fetched before
+		if msg.client != m.client {
+			break
+		}
+
 		m.refreshing = false
 		m.refreshError = false
-		m.clusterData = msg
+		m.errorPanel.SetError(nil)
 		m.lastRefresh = time.Now()
 
+		if m.clusterData == nil {
+			m.clusterData = msg.clusterData
+		} else {
+			m.clusterData.Merge(msg.clusterData)
+		}
+
+		m, cmd = m.updateScreens(msg.clusterData)
+		cmds = append(cmds, cmd)
+
+	case unassignedshardsscreen.ExplainMsg:
+		if m.client != nil {
+			cmds = append(cmds, explainAllocation(m.client, msg))
+		}
+
+	case unassignedshardsscreen.ExplanationMsg, unassignedshardsscreen.ExplanationErrorMsg:
+		m.unassignedShardsScreen, cmd = m.unassignedShardsScreen.Update(msg)
+		cmds = append(cmds, cmd)
+
 	case errMsg:
 		m.refreshing = false
 		m.err = msg
@@ -522,6 +748,221 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// updateScreens passes the current cluster data to the screens whose data
+// sources were fetched in update. Screens whose data source failed to be
+// fetched receive the error instead. The sources of the header are fetched on
+// every refresh, those of the active screen as well; the other screens are
+// updated when they become active, as their sources are stale then.
+func (m mainModel) updateScreens(update *elasticsearch.ClusterData) (mainModel, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	if update.Fetched(elasticsearch.ShardsSource) {
+		if err := m.clusterData.Errors[elasticsearch.ShardsSource]; err != nil {
+			m.shardAllocationScreen, cmd = m.shardAllocationScreen.Update(shardallocationscreen.ErrorMsg(err))
+		} else {
+			m.shardAllocationScreen, cmd = m.shardAllocationScreen.Update(
+				shardallocationscreen.ShardAllocationMsg(m.clusterData.Shards),
+			)
+		}
+		cmds = append(cmds, cmd)
+
+		if err := m.clusterData.Errors[elasticsearch.ShardsSource]; err != nil {
+			m.unassignedShardsScreen, cmd = m.unassignedShardsScreen.Update(unassignedshardsscreen.ErrorMsg(err))
+		} else {
+			m.unassignedShardsScreen, cmd = m.unassignedShardsScreen.Update(
+				unassignedshardsscreen.ShardMsg(m.clusterData.Shards),
+			)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if update.Fetched(elasticsearch.RecoveriesSource) {
+		if err := m.clusterData.Errors[elasticsearch.RecoveriesSource]; err != nil {
+			m.relocatingShardsScreen, cmd = m.relocatingShardsScreen.Update(relocatingshardsscreen.ErrorMsg(err))
+		} else {
+			m.relocatingShardsScreen, cmd = m.relocatingShardsScreen.Update(
+				relocatingshardsscreen.ShardMsg{
+					Recoveries:     m.clusterData.Recoveries,
+					FetchedAt:      m.clusterData.Updated[elasticsearch.RecoveriesSource],
+					MaxBytesPerSec: recoveryMaxBytesPerSec(m.clusterData),
+				},
+			)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if update.Fetched(elasticsearch.NodeStatsSource) || update.Fetched(elasticsearch.ClusterSettingsSource) {
+		if err := m.clusterData.Errors[elasticsearch.NodeStatsSource]; err != nil {
+			m.nodeScreen, cmd = m.nodeScreen.Update(nodescreen.ErrorMsg(err))
+		} else {
+			m.nodeScreen, cmd = m.nodeScreen.Update(
+				nodescreen.NodeMsg{
+					Nodes:          m.clusterData.NodeStats,
+					MasterNode:     m.clusterData.MasterNode,
+					Version:        m.clusterData.Version,
+					DiskWatermarks: diskWatermarks(m.clusterData),
+					Rates:          m.clusterData.NodeRates,
+				},
+			)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	// the indices are listed even if their lifecycle could not be explained,
+	// e.g. for lack of privileges
+	if update.Fetched(elasticsearch.IndexStatsSource) || update.Fetched(elasticsearch.IndexLifecycleSource) {
+		if err := m.clusterData.Errors[elasticsearch.IndexStatsSource]; err != nil {
+			m.indexScreen, cmd = m.indexScreen.Update(indexscreen.ErrorMsg(err))
+		} else {
+			m.indexScreen, cmd = m.indexScreen.Update(indexMsg(m.clusterData))
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if update.Fetched(elasticsearch.DataStreamsSource) {
+		err := m.clusterData.Errors[elasticsearch.DataStreamsSource]
+		if err == nil {
+			err = m.clusterData.Errors[elasticsearch.IndexStatsSource]
+		}
+
+		if err != nil {
+			m.dataStreamScreen, cmd = m.dataStreamScreen.Update(datastreamscreen.ErrorMsg(err))
+		} else {
+			m.dataStreamScreen, cmd = m.dataStreamScreen.Update(
+				datastreamscreen.DataStreamMsg{
+					DataStreams: m.clusterData.DataStreams,
+					Indices:     indexMsg(m.clusterData),
+				},
+			)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if update.Fetched(elasticsearch.PendingTasksSource) {
+		if err := m.clusterData.Errors[elasticsearch.PendingTasksSource]; err != nil {
+			m.pendingTasksScreen, cmd = m.pendingTasksScreen.Update(pendingtasksscreen.ErrorMsg(err))
+		} else {
+			m.pendingTasksScreen, cmd = m.pendingTasksScreen.Update(
+				pendingtasksscreen.PendingTaskMsg(m.clusterData.PendingTasks),
+			)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if update.Fetched(elasticsearch.ThreadPoolsSource) {
+		if err := m.clusterData.Errors[elasticsearch.ThreadPoolsSource]; err != nil {
+			m.threadPoolScreen, cmd = m.threadPoolScreen.Update(threadpoolscreen.ErrorMsg(err))
+		} else {
+			m.threadPoolScreen, cmd = m.threadPoolScreen.Update(
+				threadpoolscreen.ThreadPoolMsg(m.clusterData.ThreadPools),
+			)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if update.Fetched(elasticsearch.SnapshotsSource) || update.Fetched(elasticsearch.SnapshotPoliciesSource) {
+		err := m.clusterData.Errors[elasticsearch.SnapshotsSource]
+		if err == nil {
+			err = m.clusterData.Errors[elasticsearch.SnapshotPoliciesSource]
+		}
+
+		if err != nil {
+			m.snapshotScreen, cmd = m.snapshotScreen.Update(snapshotscreen.ErrorMsg(err))
+		} else {
+			m.snapshotScreen, cmd = m.snapshotScreen.Update(
+				snapshotscreen.SnapshotMsg{
+					Snapshots: m.clusterData.Snapshots,
+					Policies:  m.clusterData.SnapshotPolicies,
+				},
+			)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// resetScreens removes the data of the previous cluster from the screens, so
+// they neither show it nor compare the data of the new cluster to it.
+func (m mainModel) resetScreens() (mainModel, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	m.shardAllocationScreen, cmd = m.shardAllocationScreen.Update(shardallocationscreen.ShardAllocationMsg(nil))
+	cmds = append(cmds, cmd)
+
+	m.unassignedShardsScreen, cmd = m.unassignedShardsScreen.Update(unassignedshardsscreen.ShardMsg(nil))
+	cmds = append(cmds, cmd)
+
+	m.relocatingShardsScreen, cmd = m.relocatingShardsScreen.Update(relocatingshardsscreen.ShardMsg{})
+	cmds = append(cmds, cmd)
+
+	m.nodeScreen, cmd = m.nodeScreen.Update(nodescreen.NodeMsg{})
+	cmds = append(cmds, cmd)
+
+	m.indexScreen, cmd = m.indexScreen.Update(indexscreen.IndexMsg{})
+	cmds = append(cmds, cmd)
+
+	m.pendingTasksScreen, cmd = m.pendingTasksScreen.Update(pendingtasksscreen.PendingTaskMsg(nil))
+	cmds = append(cmds, cmd)
+
+	m.threadPoolScreen, cmd = m.threadPoolScreen.Update(threadpoolscreen.ThreadPoolMsg(nil))
+	cmds = append(cmds, cmd)
+
+	m.snapshotScreen, cmd = m.snapshotScreen.Update(snapshotscreen.SnapshotMsg{})
+	cmds = append(cmds, cmd)
+
+	m.dataStreamScreen, cmd = m.dataStreamScreen.Update(datastreamscreen.DataStreamMsg{})
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+// activeDataSources returns the data sources displayed in the header and on
+// the screen. The compact view only displays the header.
+func activeDataSources(screen screen, compactMode bool) []elasticsearch.DataSource {
+	sources := slices.Clone(headerDataSources)
+	if !compactMode {
+		sources = append(sources, screenDataSources[screen]...)
+	}
+	return sources
+}
+
+// dataSources removes the sources which are younger than their minimum age
+// from the given sources.
+func (m mainModel) dataSources(sources []elasticsearch.DataSource) []elasticsearch.DataSource {
+	if m.clusterData == nil {
+		return sources
+	}
+
+	return slices.DeleteFunc(slices.Clone(sources), func(source elasticsearch.DataSource) bool {
+		updated, ok := m.clusterData.Updated[source]
+		return ok && time.Since(updated) < dataSourceMinimumAges[source]
+	})
+}
+
+// staleDataSources returns the data sources of the active screen which were
+// not fetched in the latest refresh and are older than their minimum age.
+func (m mainModel) staleDataSources() []elasticsearch.DataSource {
+	if m.compactMode {
+		return nil
+	}
+
+	sources := m.dataSources(screenDataSources[m.screen])
+	if m.clusterData == nil {
+		return sources
+	}
+
+	return slices.DeleteFunc(sources, func(source elasticsearch.DataSource) bool {
+		return !m.clusterData.Updated[source].Before(m.clusterData.FetchedAt)
+	})
+}
+
 func (m mainModel) View() string {
 	if m.err != nil {
 		return m.err.Error()
@@ -531,33 +972,6 @@ func (m mainModel) View() string {
 		return m.loadingScreen.View()
 	}
 
-	clusterInfoTable := table.New().
-		BorderTop(false).
-		BorderRight(false).
-		BorderBottom(false).
-		BorderLeft(false).
-		BorderColumn(false).
-		StyleFunc(func(row, col int) lipgloss.Style {
-			switch col {
-			case 0:
-				return kvTableKeyStyle
-			case 1:
-				if row == 2 && m.clusterData != nil {
-					switch {
-					case m.clusterData.ClusterInfo.Status == "green":
-						return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusGreen)
-					case m.clusterData.ClusterInfo.Status == "yellow":
-						return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusYellow)
-					case m.clusterData.ClusterInfo.Status == "red":
-						return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusRed)
-					}
-				}
-				return kvTableValueStyle
-			default:
-				return lipgloss.NewStyle()
-			}
-		})
-
 	clusterName := ""
 	if m.clusterData != nil {
 		clusterName = m.clusterData.ClusterInfo.ClusterName
@@ -574,51 +988,121 @@ func (m mainModel) View() string {
 	if m.clusterData != nil {
 		clusterSize = strings.ToUpper(m.clusterData.ClusterStats.Indices.Store.Size)
 	}
+	clusterDiskWatermarks := ""
+	clusterDiskWatermarkLevel := elasticsearch.NoDiskWatermark
+	if m.clusterData != nil && m.clusterData.ClusterSettings != nil {
+		if watermarks := m.clusterData.ClusterSettings.DiskWatermarks; watermarks != nil {
+			clusterDiskWatermarks, clusterDiskWatermarkLevel = diskWatermarkCounts(m.clusterData.NodeStats, watermarks)
+		} else {
+			clusterDiskWatermarks = "disabled"
+		}
+	}
 	clusterRelocatingShards := ""
 	if m.clusterData != nil {
 		clusterRelocatingShards = fmt.Sprintf("%d", m.clusterData.ClusterInfo.RelocatingShards)
 	}
+	clusterPendingTasks := ""
+	if m.clusterData != nil {
+		clusterPendingTasks = fmt.Sprintf("%d", m.clusterData.ClusterInfo.NumberOfPendingTasks)
+	}
 	clusterActiveShardsPercent := ""
 	if m.clusterData != nil {
 		clusterActiveShardsPercent = m.clusterData.ClusterInfo.ActiveShardsPercent
 	}
+	clusterIndexSearchRates := ""
+	clusterGetRefreshMergeRates := ""
+	if m.clusterData != nil && len(m.clusterData.NodeRates) > 0 {
+		rates := m.clusterData.ClusterRates()
+		clusterIndexSearchRates = fmt.Sprintf("%.1f / %.1f", rates.Indexing, rates.Search)
+		clusterGetRefreshMergeRates = fmt.Sprintf("%.1f / %.1f / %.1f", rates.Get, rates.Refresh, rates.Merge)
+	}
+	clusterSnapshots := ""
+	if m.clusterData != nil {
+		clusterSnapshots = snapshotSummary(m.clusterData)
+	}
+	clusterVersion := ""
+	if m.clusterData != nil {
+		clusterVersion = m.clusterData.Version.String()
+	}
+
+	clusterInfoRows := [][]string{
+		{"Cluster:", clusterName},
+		{"Status:", clusterStatus},
+		{"Nodes:", clusterNodes},
+		{"Data:", clusterSize},
+		{"Disk watermarks:", clusterDiskWatermarks},
+		{"Relocating shards:", clusterRelocatingShards},
+		{"Pending tasks:", clusterPendingTasks},
+		{"Active shards:", clusterActiveShardsPercent},
+		{"Index/search [/s]:", clusterIndexSearchRates},
+		{"Get/refresh/merge [/s]:", clusterGetRefreshMergeRates},
+		{"Snapshots:", clusterSnapshots},
+		{"Version:", clusterVersion},
+	}
 
-	clusterInfoTable.Row("Cluster:", clusterName)
-	clusterInfoTable.Row("Status:", clusterStatus)
-	clusterInfoTable.Row("Nodes:", clusterNodes)
-	clusterInfoTable.Row("Data:", clusterSize)
-	clusterInfoTable.Row("Relocating shards:", clusterRelocatingShards)
-	clusterInfoTable.Row("Active shards:", clusterActiveShardsPercent)
+	// the values are colored by the label of their row
+	clusterInfoRender := renderKeyValueColumns(
+		clusterInfoRows,
+		func(row int) lipgloss.Style {
+			return kvTableKeyStyle
+		},
+		func(row int) lipgloss.Style {
+			if row < 0 || row >= len(clusterInfoRows) {
+				return kvTableValueStyle
+			}
+
+			switch clusterInfoRows[row][0] {
+			case "Status:":
+				if m.clusterData == nil {
+					break
+				}
+				switch {
+				case m.clusterData.ClusterInfo.Status == "green":
+					return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusGreen)
+				case m.clusterData.ClusterInfo.Status == "yellow":
+					return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusYellow)
+				case m.clusterData.ClusterInfo.Status == "red":
+					return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusRed)
+				}
+			case "Disk watermarks:":
+				switch clusterDiskWatermarkLevel {
+				case elasticsearch.LowDiskWatermark:
+					return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusYellow)
+				case elasticsearch.HighDiskWatermark:
+					return kvTableValueStyle.Copy().Foreground(m.theme.ForegroundColorHighlighted)
+				case elasticsearch.FloodStageDiskWatermark:
+					return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusRed)
+				}
+			case "Snapshots:":
+				if strings.HasPrefix(clusterSnapshots, snapshotFailureMarker) {
+					return kvTableValueStyle.Copy().Foreground(m.theme.BackgroundColorStatusRed)
+				}
+			}
+			return kvTableValueStyle
+		},
+	)
 
 	var commands [][]string
 	for _, keyBinding := range mainMenuKeyMap {
 		commands = append(commands, []string{keyBinding.Help().Key, keyBinding.Help().Desc})
 	}
-	commandTable := table.New().
-		Rows(commands...).
-		BorderTop(false).
-		BorderRight(false).
-		BorderBottom(false).
-		BorderLeft(false).
-		BorderColumn(false).
-		StyleFunc(func(row, col int) lipgloss.Style {
-			switch col {
-			case 0:
-				if row == int(m.screen) {
-					return kvTableKeyStyle.Copy().Foreground(m.theme.ForegroundColorHighlighted)
-				} else {
-					return kvTableKeyStyle
-				}
-			case 1:
-				if row == int(m.screen) {
-					return kvTableValueStyle.Copy().Foreground(m.theme.ForegroundColorHighlighted)
-				} else {
-					return kvTableValueStyle
-				}
-			default:
-				return lipgloss.NewStyle()
+	// the screens are declared in the order of the menu entries, the loading
+	// screen has no entry
+	commandRender := renderKeyValueColumns(
+		commands,
+		func(row int) lipgloss.Style {
+			if row+1 == int(m.screen) {
+				return kvTableKeyStyle.Copy().Foreground(m.theme.ForegroundColorHighlighted)
 			}
-		})
+			return kvTableKeyStyle
+		},
+		func(row int) lipgloss.Style {
+			if row+1 == int(m.screen) {
+				return kvTableValueStyle.Copy().Foreground(m.theme.ForegroundColorHighlighted)
+			}
+			return kvTableValueStyle
+		},
+	)
 
 	contentRender := ""
 	switch {
@@ -630,10 +1114,26 @@ func (m mainModel) View() string {
 		contentRender = m.nodeScreen.View()
 	case m.screen == indexOverview:
 		contentRender = m.indexScreen.View()
+	case m.screen == unassignedShards:
+		contentRender = m.unassignedShardsScreen.View()
+	case m.screen == pendingTasks:
+		contentRender = m.pendingTasksScreen.View()
+	case m.screen == threadPools:
+		contentRender = m.threadPoolScreen.View()
+	case m.screen == snapshots:
+		contentRender = m.snapshotScreen.View()
+	case m.screen == dataStreams:
+		contentRender = m.dataStreamScreen.View()
 	case m.screen == clusters:
 		contentRender = m.clusterScreen.View()
 	}
 
+	// errors preventing any data from being fetched replace the screen
+	// content, except for the cluster list to allow selecting another cluster
+	if m.errorPanel.Err() != nil && m.screen != clusters {
+		contentRender = m.errorPanel.View()
+	}
+
 	refreshingString := ""
 	if m.refreshing {
 		refreshingString = fmt.Sprintf("%s Refreshing", m.refreshSpinner.View())
@@ -648,6 +1148,27 @@ func (m mainModel) View() string {
 		} else {
 			refreshingString = fmt.Sprintf("%sLast refresh at %s", refreshErrorString, m.lastRefresh.Format("15:04:05"))
 		}
+
+		if m.clusterData != nil && !m.refreshError {
+			if endpoint, err := url.Parse(m.clusterData.Endpoint); err == nil && endpoint.Host != "" {
+				refreshingString = fmt.Sprintf("%s via %s", refreshingString, endpoint.Host)
+			}
+
+			switch {
+			case m.clusterData.Retries == 1:
+				refreshingString = fmt.Sprintf("%s | 1 retry", refreshingString)
+			case m.clusterData.Retries > 1:
+				refreshingString = fmt.Sprintf("%s | %d retries", refreshingString, m.clusterData.Retries)
+			}
+
+			if failedSources := m.clusterData.FailedSources(); len(failedSources) > 0 {
+				var failedSourceNames []string
+				for _, source := range failedSources {
+					failedSourceNames = append(failedSourceNames, string(source))
+				}
+				refreshingString = fmt.Sprintf("⚠ %s | Failed: %s", refreshingString, strings.Join(failedSourceNames, ", "))
+			}
+		}
 	}
 
 	statusRefreshIndicatorRender := ""
@@ -688,7 +1209,7 @@ func (m mainModel) View() string {
                 PaddingBottom(1).
                 Foreground(m.theme.ForegroundColorLight).
                 Render("<v> Normal view"),
-            clusterInfoTable.Render(),
+            clusterInfoRender,
             compactModePaddingStyle.Render(),
             statusStyle.Render(
                 lipgloss.JoinHorizontal(
@@ -708,8 +1229,8 @@ func (m mainModel) View() string {
 				infoStyle.Render(
 					lipgloss.JoinHorizontal(
 						lipgloss.Top,
-						clusterInfoStyle.Render(clusterInfoTable.Render()),
-						commandInfoStyle.Render(commandTable.Render()))),
+						clusterInfoStyle.Render(clusterInfoRender),
+						commandInfoStyle.Render(commandRender))),
 				logoStyle.Render(constants.Logo))),
 		contentStyle.Render(contentRender),
 		statusStyle.Render(
@@ -719,6 +1240,121 @@ func (m mainModel) View() string {
 				statusRefreshInfoRender)))
 }
 
+// renderKeyValueColumns renders key value rows as tables of at most
+// styles.OverviewHeight rows placed next to each other. The styles of a key and
+// a value are determined by keyStyleFunc and valueStyleFunc from the index of
+// its row.
+func renderKeyValueColumns(rows [][]string, keyStyleFunc func(row int) lipgloss.Style, valueStyleFunc func(row int) lipgloss.Style) string {
+	var columns []string
+
+	for start := 0; start < len(rows); start += styles.OverviewHeight {
+		end := min(start+styles.OverviewHeight, len(rows))
+		offset := start
+
+		column := table.New().
+			Rows(rows[start:end]...).
+			BorderTop(false).
+			BorderRight(false).
+			BorderBottom(false).
+			BorderLeft(false).
+			BorderColumn(false).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				switch col {
+				// the first row is reserved for the (omitted) headers
+				case 0:
+					return keyStyleFunc(offset + row - 1)
+				case 1:
+					return valueStyleFunc(offset + row - 1)
+				default:
+					return lipgloss.NewStyle()
+				}
+			})
+
+		if len(columns) > 0 {
+			columns = append(columns, keyValueColumnSeparator)
+		}
+		columns = append(columns, column.Render())
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+}
+
+// diskWatermarks returns the disk watermarks of the cluster settings or nil
+// if the settings are unknown or the disk threshold decider is disabled.
+func diskWatermarks(clusterData *elasticsearch.ClusterData) *elasticsearch.DiskWatermarks {
+	if clusterData.ClusterSettings == nil {
+		return nil
+	}
+	return clusterData.ClusterSettings.DiskWatermarks
+}
+
+// recoveryMaxBytesPerSec returns the recovery bandwidth limit of the cluster
+// settings or an empty string if the settings are unknown.
+func recoveryMaxBytesPerSec(clusterData *elasticsearch.ClusterData) string {
+	if clusterData.ClusterSettings == nil {
+		return ""
+	}
+	return clusterData.ClusterSettings.RecoveryMaxBytesPerSec
+}
+
+// diskWatermarkCounts returns the number of nodes whose disk usage crosses each
+// watermark and the highest watermark crossed by any node.
+func diskWatermarkCounts(nodes []elasticsearch.NodeStats, watermarks *elasticsearch.DiskWatermarks) (string, elasticsearch.DiskWatermarkLevel) {
+	var (
+		low, high, floodStage int
+		highest               elasticsearch.DiskWatermarkLevel
+	)
+
+	for _, node := range nodes {
+		level := watermarks.Level(node)
+		if level >= elasticsearch.LowDiskWatermark {
+			low++
+		}
+		if level >= elasticsearch.HighDiskWatermark {
+			high++
+		}
+		if level >= elasticsearch.FloodStageDiskWatermark {
+			floodStage++
+		}
+		highest = max(highest, level)
+	}
+
+	return fmt.Sprintf("%d low, %d high, %d flood", low, high, floodStage), highest
+}
+
+// indexMsg returns the indices with their operation rates and lifecycle.
+func indexMsg(clusterData *elasticsearch.ClusterData) indexscreen.IndexMsg {
+	return indexscreen.IndexMsg{
+		Indices:    clusterData.IndexStats,
+		Rates:      clusterData.IndexRates,
+		Lifecycles: clusterData.IndexLifecycles,
+		Version:    clusterData.Version,
+	}
+}
+
+// snapshotFailureMarker precedes the failed snapshots in the header.
+const snapshotFailureMarker = "▲"
+
+// snapshotSummary returns the number of failed snapshots, or an empty string if
+// neither snapshot lifecycle policies nor the snapshots of the repositories
+// are known. The latest snapshots are not fetched for the header on versions
+// which can not sort them.
+func snapshotSummary(clusterData *elasticsearch.ClusterData) string {
+	latestSnapshotsKnown := clusterData.Version.Supports(elasticsearch.SnapshotSortCapability) &&
+		!clusterData.Updated[elasticsearch.LatestSnapshotSource].IsZero()
+
+	if len(clusterData.SnapshotPolicies) == 0 && !latestSnapshotsKnown &&
+		clusterData.Updated[elasticsearch.SnapshotsSource].IsZero() {
+		return ""
+	}
+
+	failed := clusterData.FailedSnapshots()
+	if len(failed) == 0 {
+		return "OK"
+	}
+	return fmt.Sprintf("%s %d failed", snapshotFailureMarker, len(failed))
+}
+
 func refreshInfoStatus(refreshIntervalSeconds uint) string {
 	refreshInfoString := "Autorefresh: "
 
@@ -768,6 +1404,12 @@ func initProgram() tea.Cmd {
 			return errMsg(errors.New("Failed to parse arguments: " + err.Error()))
 		}
 
+		if args.DebugLog != "" {
+			if _, err := tea.LogToFile(args.DebugLog, "debug"); err != nil {
+				return errMsg(errors.New("Failed to open debug log file: " + err.Error()))
+			}
+		}
+
 		conf, err := config.Load(args.Config)
 		if err != nil {
 			return errMsg(errors.New("Failed to load configuratin file: " + err.Error()))
@@ -777,15 +1419,33 @@ func initProgram() tea.Cmd {
 			return errMsg(errors.New("Failed to validate configuratin file: " + err.Error()))
 		}
 
+		for _, cluster := range conf.Clusters {
+			if _, err := elasticsearch.TLSConfig(&cluster, cluster.HttpConfig(conf.Http).Insecure); err != nil {
+				return errMsg(
+					errors.New(
+						fmt.Sprintf(
+							"Failed to load TLS configuration of cluster %s: %s",
+							cluster.Alias,
+							err,
+						),
+					),
+				)
+			}
+		}
+
 		var currentCluster *config.ClusterConfig = nil
+		var client *elasticsearch.Client = nil
 		var clusterData *elasticsearch.ClusterData = nil
+		var ctx context.Context = nil
 
 		if args.Endpoint != "" {
 			conf.Clusters = []config.ClusterConfig{
 				{
-					Endpoint: args.Endpoint,
-					Username: args.Username,
-					Password: args.Password,
+					Endpoint:    args.Endpoint,
+					Username:    args.Username,
+					Password:    args.Password,
+					ApiKey:      args.ApiKey,
+					BearerToken: args.BearerToken,
 				},
 			}
 			currentCluster = &conf.Clusters[0]
@@ -811,30 +1471,25 @@ func initProgram() tea.Cmd {
 		}
 
 		if currentCluster != nil {
-			credentials, err := elasticsearch.GetCredentials(
+			client, err = newClient(
 				currentCluster,
-				&elasticsearch.Credentials{Username: args.Username, Password: args.Password},
+				&elasticsearch.Credentials{
+					Username:    args.Username,
+					Password:    args.Password,
+					ApiKey:      args.ApiKey,
+					BearerToken: args.BearerToken,
+				},
+				conf.Http,
+				args.Insecure,
 			)
 
-			var insecure = conf.Http.Insecure
-			if args.Insecure != nil {
-				insecure = *args.Insecure
-			}
-
 			if err == nil {
-				var ctx context.Context
 				ctx, refreshContextCancelFunc = context.WithCancel(context.Background())
-				clusterData, err = elasticsearch.FetchData(
-					ctx,
-					currentCluster.Endpoint,
-					credentials,
-					conf.General.RefreshInterval,
-					insecure,
-				)
+				clusterData, err = client.FetchData(ctx, activeDataSources(shardAllocation, args.CompactMode))
 			}
 		}
 
-		return initMsg{*args, *conf, currentCluster, clusterData}
+		return initMsg{*args, *conf, currentCluster, client, clusterData, ctx, err}
 	}
 }
 
@@ -864,28 +1519,44 @@ func setStyles(theme styles.Theme) {
 	kvTableValueStyle = kvTableValueStyle.Foreground(theme.ForegroundColorLight)
 }
 
-func refreshData(currentCluster *config.ClusterConfig, defaultCredentials *elasticsearch.Credentials, httpConfig config.HttpConfig) tea.Cmd {
+// newClient creates a client for the cluster. The HTTP configuration of the
+// cluster is merged over the global one and the insecure command line argument
+// takes precedence over both.
+func newClient(currentCluster *config.ClusterConfig, defaultCredentials *elasticsearch.Credentials, globalHttpConfig config.HttpConfig, insecureOverride *bool) (*elasticsearch.Client, error) {
+	credentials, err := elasticsearch.GetCredentials(currentCluster, defaultCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	httpConfig := currentCluster.HttpConfig(globalHttpConfig)
+	if insecureOverride != nil {
+		httpConfig.Insecure = *insecureOverride
+	}
+
+	return elasticsearch.NewClient(currentCluster, credentials, httpConfig)
+}
+
+// refreshData fetches the data sources with the client. The context is created
+// along with the client, so the refresh stops when the cluster is switched.
+func refreshData(ctx context.Context, client *elasticsearch.Client, sources []elasticsearch.DataSource) tea.Cmd {
 	return func() tea.Msg {
-		credentials, err := elasticsearch.GetCredentials(currentCluster, defaultCredentials)
+		clusterData, err := client.FetchData(ctx, sources)
 		if err != nil {
-			return errMsg(err)
+			return refreshErrorMsg{client, err}
 		}
 
-		var ctx context.Context
-		ctx, refreshContextCancelFunc = context.WithCancel(context.Background())
-
-		clusterData, err := elasticsearch.FetchData(
-			ctx,
-			currentCluster.Endpoint,
-			credentials,
-			httpConfig.Timeout,
-			httpConfig.Insecure,
-		)
+		return clusterDataMsg{client, clusterData}
+	}
+}
+
+func explainAllocation(client *elasticsearch.Client, shard unassignedshardsscreen.ExplainMsg) tea.Cmd {
+	return func() tea.Msg {
+		explanation, err := client.ExplainAllocation(context.Background(), shard.Index, shard.Shard, shard.Primary)
 		if err != nil {
-			return refreshErrorMsg(err)
+			return unassignedshardsscreen.ExplanationErrorMsg{ExplainMsg: shard, Err: err}
 		}
 
-		return clusterDataMsg(clusterData)
+		return unassignedshardsscreen.ExplanationMsg{ExplainMsg: shard, Explanation: explanation}
 	}
 }
 
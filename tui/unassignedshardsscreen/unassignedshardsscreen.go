@@ -0,0 +1,442 @@
+package unassignedshardsscreen
+
+import (
+	"esmon/elasticsearch"
+	"esmon/tui/errorpanel"
+	"esmon/tui/styles"
+	"esmon/tui/tableselection"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	lipglosstable "github.com/charmbracelet/lipgloss/table"
+)
+
+const (
+	// widths of the node, node decision, decider and decision columns of the
+	// explanation, the explanation column takes the remaining width
+	explanationNodeWidth         = 24
+	explanationNodeDecisionWidth = 16
+	explanationDeciderWidth      = 24
+	explanationDecisionWidth     = 12
+)
+
+var (
+	defaultTheme = styles.GetTheme(nil)
+
+	shardTableColumns []table.Column = []table.Column{
+		{Title: "↑Index [★]", Width: 20},
+		{Title: "Shard", Width: 10},
+		{Title: "Type", Width: 10},
+		{Title: "Reason", Width: 20},
+		{Title: "Unassigned since", Width: 20},
+	}
+
+	shardTableRows []table.Row
+
+	shardTableStyles = table.DefaultStyles()
+
+	summaryKeyStyle     = lipgloss.NewStyle().Width(16).Foreground(defaultTheme.ForegroundColorLightMuted)
+	summaryValueStyle   = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLight)
+	explanationStyle    = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.ForegroundColorLight)
+	explanationHeader   = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.ForegroundColorLightMuted)
+	decisionYesStyle    = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.BackgroundColorStatusGreen)
+	decisionNoStyle     = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.BackgroundColorStatusRed)
+	decisionOtherStyle  = lipgloss.NewStyle().PaddingRight(1).Foreground(defaultTheme.BackgroundColorStatusYellow)
+	explanationBorder   = lipgloss.NewStyle().Foreground(defaultTheme.BorderColorMuted)
+	explainingStyle     = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLightMuted)
+	sortingHelpStyle    = lipgloss.NewStyle().Foreground(defaultTheme.ForegroundColorLightMuted)
+	helpSeparatorString = " • "
+
+	defaultKeyMap = keyMap{
+		explain: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("<⏎>", "explain"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("<esc>", "back"),
+		),
+	}
+)
+
+type ShardMsg []elasticsearch.Shard
+type ErrorMsg error
+
+// ExplainMsg requests the allocation explanation of a shard copy.
+type ExplainMsg struct {
+	Index   string
+	Shard   int
+	Primary bool
+}
+
+// ExplanationMsg is the allocation explanation of the shard copy requested by
+// the ExplainMsg.
+type ExplanationMsg struct {
+	ExplainMsg
+	Explanation *elasticsearch.AllocationExplanation
+}
+
+// ExplanationErrorMsg reports that the shard copy requested by the ExplainMsg
+// could not be explained.
+type ExplanationErrorMsg struct {
+	ExplainMsg
+	Err error
+}
+
+type keyMap struct {
+	explain key.Binding
+	back    key.Binding
+}
+
+type Model struct {
+	width  int
+	height int
+
+	shardTable table.Model
+	selection  tableselection.Selection
+
+	// the explanation replaces the shard list while it is shown, responses
+	// for other shard copies than the explained one are dropped
+	showExplanation     bool
+	explaining          bool
+	explained           ExplainMsg
+	explanation         *elasticsearch.AllocationExplanation
+	explanationViewport viewport.Model
+
+	help help.Model
+
+	errorPanel            errorpanel.Model
+	explanationErrorPanel errorpanel.Model
+}
+
+func New(theme *styles.Theme) Model {
+	m := Model{}
+
+	m.shardTable = table.New(
+		table.WithColumns(shardTableColumns),
+		table.WithRows(shardTableRows),
+		table.WithFocused(true),
+	)
+
+	shardTableStyles.Header = shardTableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		BorderBottom(true).
+		Bold(false).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	shardTableStyles.Selected = shardTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted)).
+		Bold(false)
+	m.shardTable.SetStyles(shardTableStyles)
+
+	m.explanationViewport = viewport.New(0, 0)
+
+	m.help = help.New()
+	m.help.Styles = styles.HelpStyle
+
+	m.errorPanel = errorpanel.New(theme)
+	m.explanationErrorPanel = errorpanel.New(theme)
+
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		for index := range shardTableColumns {
+			shardTableColumns[index].Width = m.width/len(shardTableColumns) - 2
+		}
+
+		m.shardTable.SetHeight(m.height - 3)
+		m.shardTable.SetColumns(shardTableColumns)
+
+		m.explanationViewport.Width = m.width
+		m.explanationViewport.Height = m.height - 1
+		m.explanationViewport.SetContent(m.renderExplanation())
+
+		m.help.Width = m.width - 2
+
+	case styles.ThemeChangeMsg:
+		var theme = styles.Theme(msg)
+		setStyles(&theme)
+
+		m.shardTable.SetStyles(shardTableStyles)
+		m.help.Styles = styles.HelpStyle
+		m.explanationViewport.SetContent(m.renderExplanation())
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, defaultKeyMap.back) && m.showExplanation:
+			m.showExplanation = false
+			m.explaining = false
+			m.explained = ExplainMsg{}
+			m.explanation = nil
+			m.explanationErrorPanel.SetError(nil)
+
+		case key.Matches(msg, defaultKeyMap.explain) && !m.showExplanation:
+			selectedRow := m.shardTable.SelectedRow()
+			if selectedRow == nil {
+				break
+			}
+
+			shard, _ := strconv.Atoi(selectedRow[1])
+
+			m.showExplanation = true
+			m.explaining = true
+			m.explained = ExplainMsg{Index: selectedRow[0], Shard: shard, Primary: selectedRow[2] == "Primary"}
+			cmds = append(cmds, explain(m.explained))
+		}
+
+	case ErrorMsg:
+		m.errorPanel.SetError(msg)
+
+	case ShardMsg:
+		m.errorPanel.SetError(nil)
+
+		var (
+			shardTableRows []table.Row
+			rowKeys        []string
+		)
+
+		// the unassigned replicas of a shard are told apart by their
+		// position among each other
+		copies := make(map[string]int)
+
+		for _, row := range msg {
+			if row.State != elasticsearch.ShardStateUnassigned {
+				continue
+			}
+
+			shardType := "Replica"
+			if row.Primary() {
+				shardType = "Primary"
+			}
+
+			rowKey := fmt.Sprintf("%s/%s/%s", row.Index, row.Shard, row.PriRep)
+			rowKeys = append(rowKeys, fmt.Sprintf("%s/%d", rowKey, copies[rowKey]))
+			copies[rowKey]++
+			shardTableRows = append(shardTableRows, table.Row{
+				row.Index,
+				row.Shard,
+				shardType,
+				row.UnassignedReason,
+				row.UnassignedAt,
+			})
+		}
+
+		m.selection.SetRows(&m.shardTable, shardTableRows, rowKeys)
+
+	case ExplanationMsg:
+		if !m.showExplanation || msg.ExplainMsg != m.explained {
+			break
+		}
+
+		m.explaining = false
+		m.explanation = msg.Explanation
+		m.explanationErrorPanel.SetError(nil)
+		m.explanationViewport.SetContent(m.renderExplanation())
+		m.explanationViewport.GotoTop()
+
+	case ExplanationErrorMsg:
+		if !m.showExplanation || msg.ExplainMsg != m.explained {
+			break
+		}
+
+		m.explaining = false
+		m.explanationErrorPanel.SetError(msg.Err)
+	}
+
+	if m.showExplanation {
+		m.explanationViewport, cmd = m.explanationViewport.Update(msg)
+	} else {
+		m.shardTable, cmd = m.shardTable.Update(msg)
+	}
+	cmds = append(cmds, cmd)
+
+	m.errorPanel, cmd = m.errorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.explanationErrorPanel, cmd = m.explanationErrorPanel.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	if m.showExplanation {
+		content := m.explanationViewport.View()
+		switch {
+		case m.explanationErrorPanel.Err() != nil:
+			content = m.explanationErrorPanel.View()
+		case m.explaining:
+			content = explainingStyle.Render("Explaining allocation …")
+		}
+
+		return lipgloss.JoinVertical(
+			lipgloss.Top,
+			content,
+			m.help.ShortHelpView([]key.Binding{defaultKeyMap.back}),
+		)
+	}
+
+	if m.errorPanel.Err() != nil {
+		return m.errorPanel.View()
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		m.shardTable.View(),
+		sortingHelpStyle.Render("[★] Sorting by index first, shard second, primary third")+
+			sortingHelpStyle.Render(helpSeparatorString)+
+			m.help.ShortHelpView([]key.Binding{defaultKeyMap.explain}),
+	)
+}
+
+// renderExplanation renders the summary of the explanation followed by a
+// table of the decider decisions of every node.
+func (m Model) renderExplanation() string {
+	if m.explanation == nil {
+		return ""
+	}
+
+	explanation := m.explanation
+
+	shardType := "replica"
+	if explanation.Primary {
+		shardType = "primary"
+	}
+
+	summary := [][]string{
+		{"Shard:", fmt.Sprintf("%s[%d] %s", explanation.Index, explanation.Shard, shardType)},
+		{"State:", explanation.CurrentState},
+		{"Can allocate:", explanation.CanAllocate},
+		{"Explanation:", explanation.AllocateExplanation},
+		{"Reason:", explanation.UnassignedInfo.Reason},
+		{"Since:", explanation.UnassignedInfo.At},
+		{"Last status:", explanation.UnassignedInfo.LastAllocationStatus},
+		{"Details:", explanation.UnassignedInfo.Details},
+	}
+
+	summaryValueWidth := max(m.width-summaryKeyStyle.GetWidth(), 0)
+
+	var lines []string
+	for _, row := range summary {
+		if row[1] == "" {
+			continue
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			summaryKeyStyle.Render(row[0]),
+			summaryValueStyle.Copy().Width(summaryValueWidth).Render(row[1]),
+		))
+	}
+
+	var rows [][]string
+	for _, node := range explanation.NodeAllocationDecisions {
+		if len(node.Deciders) == 0 {
+			rows = append(rows, []string{node.NodeName, node.NodeDecision, "", "", ""})
+			continue
+		}
+
+		for index, decider := range node.Deciders {
+			nodeName, nodeDecision := "", ""
+			if index == 0 {
+				nodeName, nodeDecision = node.NodeName, node.NodeDecision
+			}
+			rows = append(rows, []string{nodeName, nodeDecision, decider.Decider, decider.Decision, decider.Explanation})
+		}
+	}
+
+	if len(rows) > 0 {
+		explanationWidth := max(
+			m.width-explanationNodeWidth-explanationNodeDecisionWidth-explanationDeciderWidth-explanationDecisionWidth,
+			20,
+		)
+		widths := []int{
+			explanationNodeWidth,
+			explanationNodeDecisionWidth,
+			explanationDeciderWidth,
+			explanationDecisionWidth,
+			explanationWidth,
+		}
+
+		nodeTable := lipglosstable.New().
+			Headers("Node", "Node decision", "Decider", "Decision", "Explanation").
+			Rows(rows...).
+			BorderTop(false).
+			BorderRight(false).
+			BorderBottom(false).
+			BorderLeft(false).
+			BorderColumn(false).
+			BorderHeader(true).
+			BorderStyle(explanationBorder).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				style := explanationStyle
+				switch {
+				case row == 0:
+					style = explanationHeader
+				case col == 1 || col == 3:
+					style = decisionStyle(rows[row-1][col])
+				}
+				return style.Copy().Width(widths[col])
+			})
+
+		lines = append(lines, "", nodeTable.Render())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func decisionStyle(decision string) lipgloss.Style {
+	switch strings.ToLower(decision) {
+	case "yes":
+		return decisionYesStyle
+	case "no":
+		return decisionNoStyle
+	default:
+		return decisionOtherStyle
+	}
+}
+
+func setStyles(theme *styles.Theme) {
+	shardTableStyles.Header = shardTableStyles.Header.
+		BorderForeground(lipgloss.Color(theme.BorderColorMuted)).
+		Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	shardTableStyles.Selected = shardTableStyles.Selected.
+		Foreground(lipgloss.Color(theme.ForegroundColorHighlighted))
+
+	summaryKeyStyle = summaryKeyStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	summaryValueStyle = summaryValueStyle.Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	explanationStyle = explanationStyle.Foreground(lipgloss.Color(theme.ForegroundColorLight))
+	explanationHeader = explanationHeader.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	decisionYesStyle = decisionYesStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusGreen))
+	decisionNoStyle = decisionNoStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusRed))
+	decisionOtherStyle = decisionOtherStyle.Foreground(lipgloss.Color(theme.BackgroundColorStatusYellow))
+	explanationBorder = explanationBorder.Foreground(lipgloss.Color(theme.BorderColorMuted))
+	explainingStyle = explainingStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+	sortingHelpStyle = sortingHelpStyle.Foreground(lipgloss.Color(theme.ForegroundColorLightMuted))
+}
+
+func explain(shard ExplainMsg) tea.Cmd {
+	return func() tea.Msg {
+		return shard
+	}
+}